@@ -0,0 +1,125 @@
+package ini
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Warning describes a use of an old or deprecated key name in the file,
+// see [Decoder.Warnings].
+type Warning struct {
+	Section string
+	Key     string // Name of the key as it is written in the file.
+	File    string // Name of the file, if known.
+	Line    int    // Zero if the key is not written in a file.
+	Column  int    // Zero if the key is not written in a file.
+	Message string
+}
+
+func (w Warning) String() string {
+	buf := strings.Builder{}
+	buf.WriteString("[" + w.Section + "] " + w.Key)
+	if w.Line > 0 && w.File != "" {
+		fmt.Fprintf(&buf, " (%s, line %d)", w.File, w.Line)
+	} else if w.Line > 0 {
+		fmt.Fprintf(&buf, " (line %d)", w.Line)
+	}
+	buf.WriteString(": " + w.Message)
+	return buf.String()
+}
+
+// Warnings sets the function called for every key of the file that is
+// an alias of another key or is deprecated (see the 'alias' and
+// 'deprecated' tags in the [SectionsOf] documentation).
+//
+// A key written under an alias is decoded into the field of the key.
+// If the key is written under both names, the alias is ignored, unless
+// only the alias is written in the section and the key is inherited.
+// Inherited keys are reported once, for the section they are written in.
+func (d *Decoder) Warnings(warn func(w Warning)) *Decoder {
+	d.warn = warn
+	return d
+}
+
+// resolveAliases renames keys of the document written under aliases and
+// reports uses of aliases and deprecated keys.
+func (d *Decoder) resolveAliases(doc *document, sections []Section) {
+	reported := map[[2]string]bool{}
+	report := func(section, name string, key docKey, msg string) {
+		if key.inherited != "" {
+			decoded := slices.ContainsFunc(sections, func(s Section) bool {
+				return d.normalizeName(s.Name) == d.normalizeName(key.inherited)
+			})
+			if decoded || reported[[2]string{key.inherited, name}] {
+				return
+			}
+			section = key.inherited
+		}
+		reported[[2]string{section, name}] = true
+		d.warning(section, name, key, msg)
+	}
+
+	for _, section := range sections {
+		s := doc.section(section.Name)
+		if s == nil {
+			continue
+		}
+
+		for _, field := range section.Fields {
+			written := field.Name
+			for _, alias := range field.Aliases {
+				i := slices.IndexFunc(s.keys, func(key docKey) bool {
+					return key.name == alias
+				})
+				if i < 0 {
+					continue
+				}
+				key := s.keys[i]
+
+				msg := fmt.Sprintf("'%s' is an old name of '%s'", alias, field.Name)
+				j := slices.IndexFunc(s.keys, func(key docKey) bool {
+					return key.name == field.Name
+				})
+				switch {
+				case j >= 0 && (s.keys[j].inherited == "" || key.inherited != ""):
+					if key.inherited == "" {
+						msg += ", the key is ignored"
+					}
+					s.keys = slices.Delete(s.keys, i, i+1)
+
+				case j >= 0:
+					s.keys[i].name = field.Name
+					s.keys = slices.Delete(s.keys, j, j+1)
+					written = alias
+
+				default:
+					s.keys[i].name = field.Name
+					written = alias
+				}
+				report(section.Name, alias, key, msg)
+			}
+
+			if field.Deprecated == "" {
+				continue
+			}
+			if key, ok := s.key(field.Name); ok {
+				report(section.Name, written, key, field.Deprecated)
+			}
+		}
+	}
+}
+
+func (d *Decoder) warning(section, name string, key docKey, msg string) {
+	if d.warn == nil {
+		return
+	}
+	d.warn(Warning{
+		Section: section,
+		Key:     name,
+		File:    key.file,
+		Line:    int(key.line),
+		Column:  int(key.column),
+		Message: msg,
+	})
+}
@@ -0,0 +1,89 @@
+package ini_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/saffage/go-ini"
+)
+
+func TestAliases(t *testing.T) {
+	type Server struct {
+		Host    string `ini:"host" alias:"hostname, server_name"`
+		Port    int    `ini:"port" alias:"listen_port"`
+		Timeout int    `ini:"timeout" deprecated:"use 'read_timeout' instead"`
+		Legacy  bool   `ini:"legacy" alias:"old_legacy" deprecated:""`
+	}
+	type file struct {
+		Server Server `ini:"server"`
+	}
+
+	const data = "[server]\nserver_name=example.com\nport=80\nlisten_port=8080\n" +
+		"timeout=30\nold_legacy=true\n"
+
+	var warnings []string
+	var f file
+	err := ini.NewDecoder(strings.NewReader(data)).
+		Warnings(func(w ini.Warning) { warnings = append(warnings, w.String()) }).
+		Decode(&f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := Server{Host: "example.com", Port: 80, Timeout: 30, Legacy: true}
+	if f.Server != expect {
+		t.Errorf("unexpected value: %+v", f.Server)
+	}
+
+	expectWarnings := []string{
+		"[server] server_name (line 2): 'server_name' is an old name of 'host'",
+		"[server] listen_port (line 4): 'listen_port' is an old name of 'port', " +
+			"the key is ignored",
+		"[server] timeout (line 5): use 'read_timeout' instead",
+		"[server] old_legacy (line 6): 'old_legacy' is an old name of 'legacy'",
+		"[server] old_legacy (line 6): deprecated",
+	}
+	if !reflect.DeepEqual(warnings, expectWarnings) {
+		t.Errorf("unexpected warnings:\n%s", strings.Join(warnings, "\n"))
+	}
+
+	testMarshal(
+		t,
+		"[server]\nhost='example.com'\nport=80\ntimeout=30\nlegacy=true\n",
+		f,
+	)
+
+	t.Run("inherited keys", func(t *testing.T) {
+		var f struct {
+			Server Server `ini:"server"`
+			Backup Server `ini:"backup"`
+			Spare  Server `ini:"spare"`
+		}
+		const data = "[DEFAULT]\nhostname=localhost\nport=1\n" +
+			"[server]\nhost=example.com\n[backup : server]\nlisten_port=2\n" +
+			"[spare]\n"
+
+		var warnings []string
+		err := ini.NewDecoder(strings.NewReader(data)).
+			Warnings(func(w ini.Warning) { warnings = append(warnings, w.String()) }).
+			Decode(&f)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if f.Server.Host != "example.com" || f.Server.Port != 1 ||
+			f.Backup.Host != "example.com" || f.Backup.Port != 2 ||
+			f.Spare.Host != "localhost" || f.Spare.Port != 1 {
+			t.Errorf("unexpected value: %+v", f)
+		}
+
+		expectWarnings := []string{
+			"[DEFAULT] hostname (line 2): 'hostname' is an old name of 'host'",
+			"[backup] listen_port (line 7): 'listen_port' is an old name of 'port'",
+		}
+		if !reflect.DeepEqual(warnings, expectWarnings) {
+			t.Errorf("unexpected warnings:\n%s", strings.Join(warnings, "\n"))
+		}
+	})
+}
@@ -6,10 +6,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"reflect"
 	"slices"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 // Unmarshaler interface can be implemented to customize an INI tree
@@ -34,6 +36,22 @@ type SectionUnmarshaler interface {
 //
 // Unmarshal supports tags for structure fields, more information can be found
 // in the [SectionsOf] function documentation.
+//
+// A section can inherit keys it does not set from a parent section:
+//
+//	[base]
+//	host=localhost
+//	port=8080
+//	[prod : base]
+//	host=example.com
+//
+// A header whose parent is a declared profile denotes a profile section
+// instead, see [Decoder.Profiles].
+//
+// Keys of the section named "DEFAULT" fall back into every other section
+// of the file, after the keys of parent sections. The section itself is
+// not decoded. Inherited keys are decoded only into fields of a section,
+// they are not added to its 'remain' map.
 func Unmarshal(data []byte, value any) error {
 	r := bytes.NewReader(data)
 	d := Decoder{}
@@ -43,8 +61,40 @@ func Unmarshal(data []byte, value any) error {
 
 // Decoder reads and decodes an INI file from the specified input.
 type Decoder struct {
-	r io.Reader
+	r    io.Reader
+	tree treeOptions
+	file string // Name of the file being decoded, if known.
 	scanner
+
+	fsys         fs.FS
+	includeOpts  IncludeOptions
+	includeStack []string
+
+	inferTypes         bool
+	profile            string
+	profiles           []string
+	warn               func(Warning)
+	foldCase           bool
+	normalize          func(string) string
+	interpolate        bool
+	interpolatePercent bool
+	expandEnv          bool
+	env                bool
+	envPrefix          string
+	lookupEnv          func(string) (string, bool)
+}
+
+// NewDecoder creates a new [Decoder] that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: r}
+}
+
+// InferTypes allows the decoder to convert values decoded into untyped
+// targets (`any`) to bool, int64, float64 or []any, when they look like
+// one. Quoted values are always kept as strings.
+func (d *Decoder) InferTypes(flag bool) *Decoder {
+	d.inferTypes = flag
+	return d
 }
 
 // Reset resets the decoder to read from w, keeping all of its settings.
@@ -55,152 +105,476 @@ func (d *Decoder) Reset(r io.Reader) *Decoder {
 
 // Decode deserializes an INI file into a Go value.
 //
+// Besides the types listed in the [SectionsOf] documentation, value can be
+// a pointer to map[string]S or []Section. New sections and keys are added to
+// such values in the order they appear in the file.
+//
+// After decoding, constraints of the fields are checked and [Validator]
+// implementations are called, every violation is reported in a single
+// [ValidationError].
+//
 // More information can be found in the [Unmarshal] function documentation.
 func (d *Decoder) Decode(value any) error {
-	sections, err := SectionsOf(value)
+	v := reflect.ValueOf(value)
+	if !isUntypedTarget(v) {
+		// Report unsupported values before reading the input.
+		if _, err := d.tree.sectionsOf(value); err != nil {
+			return err
+		}
+	}
+
+	doc, err := d.read()
+	if err != nil {
+		return err
+	}
+
+	return d.decodeDocument(doc, value, nil, nil)
+}
+
+// decodeDocument decodes the document into the value. If meta is not nil,
+// it is filled with the keys of the document, and keys of unknown sections
+// are reported as undecoded instead of failing. If origins is not nil, it
+// is filled with the origins of the keys as they are decoded.
+func (d *Decoder) decodeDocument(
+	doc *document,
+	value any,
+	meta *MetaData,
+	origins Origins,
+) error {
+	if v := reflect.ValueOf(value); isUntypedTarget(v) {
+		d.normalizeNames(doc, nil)
+		if err := d.process(doc, nil); err != nil {
+			return err
+		}
+		meta.collect(doc, nil)
+		origins.collect(doc)
+		return d.decodeUntyped(doc, v.Elem())
+	}
+
+	sections, err := d.tree.sectionsOf(value)
 	if err != nil {
 		return err
 	}
 
+	// Names are matched before variants are resolved to find their
+	// sections, and again to match keys of the variants.
+	d.normalizeNames(doc, sections)
+
+	// Variants are named by the expanded values, before the document is
+	// processed with the sections of the variants.
+	restore, err := resolveVariants(&d.tree, doc, value, d.expander(doc, sections))
+	defer restore()
+	if err != nil {
+		return err
+	}
+
+	sections, err = d.tree.sectionsOf(value)
+	if err != nil {
+		return err
+	}
+
+	d.normalizeNames(doc, sections)
+	d.resolveAliases(doc, sections)
+
+	if err := d.process(doc, sections); err != nil {
+		return err
+	}
+
+	meta.collect(doc, sections)
+	origins.collect(doc)
+
+	remain := remainOf(reflect.Indirect(reflect.ValueOf(value)))
+	err = d.apply(doc, sections, remain, meta)
+	restore()
+	if err != nil {
+		return err
+	}
+
+	return validate(doc, sections, reflect.ValueOf(value))
+}
+
+func (d *Decoder) read() (*document, error) {
 	b, err := io.ReadAll(d.r)
 	if err != nil {
-		return fmt.Errorf("read failed: %w", err)
+		return nil, fmt.Errorf("read failed: %w", err)
 	}
 
 	d.init(b)
-	return d.scan(sections)
+	return d.scan()
 }
 
-func (d *Decoder) scan(sections []Section) error {
-	currentSection := (*Section)(nil)
+// expander returns a function that expands references in the value of
+// a key as [Decoder.process] does, without changing the document.
+func (d *Decoder) expander(doc *document, sections []Section) func(*docSection, docKey) (string, error) {
+	if d.interpolate || d.interpolatePercent || d.expandEnv {
+		return d.interpolator(doc, sections).resolve
+	}
+	return func(_ *docSection, key docKey) (string, error) {
+		return key.value.text, nil
+	}
+}
 
-	for char := d.peek(); ; char = d.peek() {
+// process resolves references in values of the document and adds keys
+// from the environment. Sections are nil for untyped targets.
+func (d *Decoder) process(doc *document, sections []Section) error {
+	if d.interpolate || d.interpolatePercent || d.expandEnv {
+		if err := d.interpolateDocument(doc, sections); err != nil {
+			return err
+		}
+	}
+
+	if d.env {
+		d.overlayEnv(doc, sections)
+	}
+
+	return nil
+}
+
+func (d *Decoder) scan() (*document, error) {
+	doc := &document{}
+	if err := d.scanInto(doc, nil); err != nil {
+		return nil, err
+	}
+	if err := doc.inherit(d.inheritOptions()); err != nil {
+		return nil, d.fileError(err)
+	}
+	return doc, nil
+}
+
+// scanInto parses the buffer and adds its sections and keys to the
+// document. Keys before the first section header are added to
+// the current section, if any.
+func (d *Decoder) scanInto(doc *document, currentSection *docSection) error {
+	for {
 		d.skipSpaces()
+		char := d.peek()
+		line, column := d.lineNum, d.charNum
 
 		switch {
 		case char == '\000':
 			return nil
 
 		case isNewlineChar(char):
-			continue
+			// Empty line.
+
+		case d.directive() != "":
+			directive := d.directive()
+			for range len(directive) {
+				d.advance()
+			}
+
+			name := strings.TrimSpace(d.takeUntil(isNewlineChar))
+			isDir := directive == d.includeOpts.DirDirective
+
+			if err := d.include(doc, currentSection, name, isDir, line, column); err != nil {
+				return err
+			}
 
 		case isNameChar(char):
 			fieldName := d.name()
 
-			if !d.consume('=') {
-				return errUnexpectedChar(d.peek(), d.lineNum, d.charNum)
+			if !d.consume('=') && !d.consume(':') {
+				return d.fileError(errUnexpectedChar(d.peek(), d.lineNum, d.charNum))
 			}
 
 			value, err := d.value()
 			if err != nil {
-				return err
-			}
-
-			if currentSection == nil {
-				return errors.New("key must be under section")
+				return d.fileError(err)
 			}
 
-			if field, present := currentSection.Field(fieldName); present {
-				err := decode(strings.TrimSpace(value), field.Value)
+			if d.isIncludeKey(fieldName) {
+				err := d.include(doc, currentSection, value.text, false, line, column)
 				if err != nil {
 					return err
 				}
+				break
 			}
 
+			if currentSection == nil {
+				return d.fileError(errors.New("key must be under section"))
+			}
+
+			currentSection.set(docKey{
+				name:   fieldName,
+				value:  value,
+				file:   d.file,
+				line:   line,
+				column: column,
+			})
+
 		case char == '[':
 			d.advance()
-			sectionName := d.name()
+			sectionName := strings.TrimSpace(d.takeUntil(func(char byte) bool {
+				return char == ']' || isNewlineChar(char)
+			}))
 
 			if sectionName == "" || !d.consume(']') {
-				return errUnexpectedChar(d.peek(), d.lineNum, d.charNum)
+				return d.fileError(errUnexpectedChar(d.peek(), d.lineNum, d.charNum))
 			}
 
-			if currentSection = findSection(
-				sections,
-				sectionName,
-			); currentSection == nil {
-				return fmt.Errorf("unknown section named '%s'", sectionName)
+			sectionName, parent, inherits := strings.Cut(sectionName, ":")
+			sectionName = strings.TrimSpace(sectionName)
+			parent = strings.TrimSpace(parent)
+
+			if inherits && (sectionName == "" || parent == "") {
+				return d.fileError(fmt.Errorf(
+					"expected 'section : parent' at %d:%d",
+					line,
+					column,
+				))
 			}
 
+			d.skipSpaces()
+			currentSection = doc.addHeader(sectionName, parent, d.file, line, column)
+
 		case char == '#', char == ';':
 			d.takeUntil(isNewlineChar)
 
 		default:
-			return errUnexpectedChar(char, d.lineNum, d.charNum)
+			return d.fileError(errUnexpectedChar(char, d.lineNum, d.charNum))
 		}
 
-		if !d.handleNewline() {
-			return errExpectedNewLine(int(d.lineNum), int(d.charNum))
+		if !d.handleNewline() && d.peek() != '\000' {
+			return d.fileError(errExpectedNewLine(int(d.lineNum), int(d.charNum)))
 		}
 	}
 }
 
-func (d *Decoder) skipSpaces() {
-	d.takeWhile(func(char byte) bool { return char == ' ' })
+// fileError prefixes the error with the name of the file being decoded.
+func (d *Decoder) fileError(err error) error {
+	if d.file == "" {
+		return err
+	}
+	return fmt.Errorf("%s: %w", d.file, err)
 }
 
-func (d *Decoder) name() string {
-	d.skipSpaces()
-	name := d.takeWhile(isNameCharOrDigit)
-	d.skipSpaces()
-	return name
-}
+// apply decodes the keys of the document into the sections. Sections
+// that do not exist in the value are decoded into the remain map, if it
+// is valid.
+func (d *Decoder) apply(
+	doc *document,
+	sections []Section,
+	remain reflect.Value,
+	meta *MetaData,
+) error {
+	for _, s := range doc.sections {
+		section := findSection(sections, s.name)
+		if section != nil && section.value.IsValid() &&
+			section.value.Type() == tRawSection {
+			decodeRawSection(section, s)
+			continue
+		}
+		if section == nil && remain.IsValid() {
+			if err := d.decodeRemainSection(remain, s); err != nil {
+				return err
+			}
+			continue
+		}
+		if section == nil && meta != nil {
+			for _, key := range s.keys {
+				if key.inherited == "" {
+					meta.undecoded = append(meta.undecoded, metaKey(s, key))
+				}
+			}
+			continue
+		}
+		if section == nil {
+			return fmt.Errorf("unknown section named '%s'", s.name)
+		}
 
-func (d *Decoder) value() (string, error) {
-	d.skipSpaces()
+		for _, key := range s.keys {
+			decoded, err := d.decodeKey(section, key)
+			if err != nil {
+				return err
+			}
+			if !decoded && meta != nil && key.inherited == "" {
+				meta.undecoded = append(meta.undecoded, metaKey(s, key))
+			}
+		}
+	}
 
-	value := strings.Builder{}
+	return applyDefaults(doc, sections)
+}
 
-	switch {
-	case d.peek() == '\'':
-		d.advance()
-		s, err := d.take(d.takeStringChar)
-		if err != nil {
-			return "", err
+// applyDefaults decodes default values of fields whose keys are missing
+// in the file. Sections built from nil pointers are left untouched unless
+// they are present in the file.
+func applyDefaults(doc *document, sections []Section) error {
+	for _, section := range sections {
+		s := doc.section(section.Name)
+		if s == nil && section.null {
+			continue
 		}
-		value.WriteString(s)
-		d.advance()
 
-	case d.peek() == '"':
-		d.advance()
-		value.WriteString(d.takeUntil(func(char byte) bool {
-			return char == '"'
-		}))
-		d.advance()
+		for _, field := range section.Fields {
+			if field.Default == "" {
+				continue
+			}
+			if s != nil {
+				if _, present := s.key(field.Name); present {
+					continue
+				}
+			}
+			if err := decode(field.Default, field.Value); err != nil {
+				return fmt.Errorf(
+					"invalid default value of key '%s' in section '%s': %w",
+					field.Name,
+					section.Name,
+					err,
+				)
+			}
+			if field.alloc != nil {
+				field.alloc()
+			}
+		}
+	}
 
-	case isDigit(d.peek()):
-		value.WriteString(d.takeWhile(isDigit))
-		if d.consume('.') {
-			value.WriteByte('.')
-			value.WriteString(d.takeWhile(isDigit))
+	return nil
+}
+
+// decodeKey decodes the key into the field or the map entry of the section.
+// It reports whether the section has a field for the key.
+func (d *Decoder) decodeKey(section *Section, key docKey) (bool, error) {
+	if section.m.IsValid() {
+		if err := d.decodeMapEntry(section.m, key); err != nil {
+			return true, err
 		}
+		if section.alloc != nil {
+			section.alloc()
+		}
+		return true, nil
+	}
 
-	case isNameChar(d.peek()):
-		value.WriteString(d.takeWhile(isNameCharOrDigit))
+	field, present := section.Field(key.name)
+	if (!present || field.remain) && key.inherited != "" {
+		// Inherited keys are decoded only into fields of the section.
+		return false, nil
+	}
+	if !present || field.remain {
+		return d.decodeRemainKey(section, key)
+	}
+	if err := decodeValue(key, field.Value); err != nil {
+		return true, err
+	}
+	if field.alloc != nil {
+		field.alloc()
+	}
+	return true, nil
+}
 
-	case isNewlineChar(d.peek()):
-		// Empty value.
+// decodeRemainKey decodes the key into the ',remain' map of the section.
+// It reports whether the section has such a map.
+func (d *Decoder) decodeRemainKey(section *Section, key docKey) (bool, error) {
+	remain := remainOf(section.value)
+	if !remain.IsValid() {
+		return false, nil
+	}
+	if err := d.decodeMapEntry(remain, key); err != nil {
+		return true, err
+	}
+	if section.alloc != nil {
+		section.alloc()
+	}
+	return true, nil
+}
 
-	default:
-		return "", errUnexpectedChar(d.peek(), d.lineNum, d.charNum)
+// decodeRemainSection decodes the section into the ',remain' map of
+// the root value.
+func (d *Decoder) decodeRemainSection(remain reflect.Value, s *docSection) error {
+	if remain.IsNil() {
+		remain.Set(reflect.MakeMap(remain.Type()))
 	}
 
+	name := reflect.ValueOf(s.name).Convert(remain.Type().Key())
+	m := remain.MapIndex(name)
+	if !m.IsValid() || m.IsNil() {
+		m = reflect.MakeMap(remain.Type().Elem())
+	}
+
+	for _, key := range s.keys {
+		if err := d.decodeMapEntry(m, key); err != nil {
+			return err
+		}
+	}
+
+	remain.SetMapIndex(name, m)
+	return nil
+}
+
+func (d *Decoder) skipSpaces() {
+	d.takeWhile(func(char byte) bool { return char == ' ' || char == '\t' })
+}
+
+func (d *Decoder) name() string {
+	d.skipSpaces()
+	name := d.takeWhile(isKeyChar)
+	d.skipSpaces()
+	return name
+}
+
+func (d *Decoder) value() (docValue, error) {
+	value := docValue{}
+	items := []string{}
+
 	d.skipSpaces()
+	start := d.bufPos
 
-	if d.consume(',') {
-		v, err := d.value()
-		if err != nil {
+	for {
+		d.skipSpaces()
+
+		switch {
+		case d.peek() == '\'':
+			d.advance()
+			s, err := d.take(d.takeStringChar)
+			if err != nil {
+				return value, err
+			}
+			if !d.consume('\'') {
+				return value, errUnterminatedString(d.lineNum, d.charNum)
+			}
+			items = append(items, s)
+			value.quoted = append(value.quoted, true)
+
+		case d.peek() == '"':
+			// Double-quoted strings are taken verbatim, without escapes.
+			d.advance()
+			s := d.takeUntil(func(char byte) bool {
+				return char == '"' || isNewlineChar(char)
+			})
+			if !d.consume('"') {
+				return value, errUnterminatedString(d.lineNum, d.charNum)
+			}
+			items = append(items, s)
+			value.quoted = append(value.quoted, true)
+
+		default:
+			s := d.takeUntil(func(char byte) bool {
+				return char == ',' || char == ';' || isNewlineChar(char)
+			})
+			items = append(items, strings.TrimRight(s, " \t"))
+			value.quoted = append(value.quoted, false)
+		}
+
+		d.skipSpaces()
 
+		if !d.consume(',') {
+			break
 		}
-		value.WriteByte(',')
-		value.WriteString(v)
 	}
 
+	value.raw = strings.TrimRight(string(d.buf[start:d.bufPos]), " \t")
+
 	if d.consume(';') {
 		d.takeUntil(isNewlineChar)
 	}
 
-	return value.String(), nil
+	if !isNewlineChar(d.peek()) && d.peek() != '\000' {
+		return value, errUnexpectedChar(d.peek(), d.lineNum, d.charNum)
+	}
+
+	value.text = strings.Join(items, ",")
+	value.list = len(items) > 1
+	return value, nil
 }
 
 func (d *Decoder) takeStringChar() ([]byte, error) {
@@ -244,21 +618,46 @@ func (d *Decoder) takeStringChar() ([]byte, error) {
 		bytes := [2]byte{}
 		decoded := [1]byte{}
 		if !isHexDigit(d.peek()) || !isHexDigit(d.lookAhead(1)) {
-			panic("TODO")
+			return nil, errors.New("invalid escape sequence")
 		}
 		bytes[0] = d.advance()
 		bytes[1] = d.advance()
 		_, err := hex.Decode(decoded[:], bytes[:])
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
 		return decoded[:], nil
 
+	case 'u':
+		return d.takeUnicodeEscape(4)
+
+	case 'U':
+		return d.takeUnicodeEscape(8)
+
 	default:
 		return nil, errors.New("invalid escape sequence")
 	}
 }
 
+// takeUnicodeEscape decodes a code point of the specified number of
+// hexadecimal digits and returns it encoded in UTF-8.
+func (d *Decoder) takeUnicodeEscape(digits int) ([]byte, error) {
+	for i := range digits {
+		if !isHexDigit(d.lookAhead(i)) {
+			return nil, errors.New("invalid escape sequence")
+		}
+	}
+	s := d.buf[d.bufPos : d.bufPos+digits]
+	for range digits {
+		d.advance()
+	}
+	r, err := strconv.ParseUint(string(s), 16, 32)
+	if err != nil || !utf8.ValidRune(rune(r)) {
+		return nil, errors.New("invalid escape sequence")
+	}
+	return utf8.AppendRune(nil, rune(r)), nil
+}
+
 func findSection(sections []Section, name string) *Section {
 	idx := slices.IndexFunc(sections, func(section Section) bool {
 		return section.Name == name
@@ -278,6 +677,11 @@ func decode(str string, v reflect.Value) error {
 		return errors.New("value cannot be set")
 	}
 
+	if v.Type() == tRawValue {
+		v.Set(reflect.ValueOf(RawValue{Text: str}))
+		return nil
+	}
+
 	switch v.Kind() {
 	case reflect.Bool:
 		x, err := strconv.ParseBool(str)
@@ -351,6 +755,10 @@ func isNameCharOrDigit(char byte) bool {
 	return isNameChar(char) || isDigit(char)
 }
 
+func isKeyChar(char byte) bool {
+	return isNameCharOrDigit(char) || char == '-' || char == '.'
+}
+
 func isNewlineChar(char byte) bool {
 	return char == '\n' || char == '\r'
 }
@@ -359,6 +767,10 @@ func errUnexpectedChar(char byte, line, column uint32) error {
 	return fmt.Errorf("unexpected character '%c' at %d:%d", char, line, column)
 }
 
+func errUnterminatedString(line, column uint32) error {
+	return fmt.Errorf("unterminated string at %d:%d", line, column)
+}
+
 func errExpectedNewLine(line, column int) error {
 	return fmt.Errorf("expected new line at %d:%d", line, column)
 }
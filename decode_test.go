@@ -1,26 +1,58 @@
 package ini_test
 
 import (
+	"bytes"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/saffage/go-ini"
 )
 
-// FIXME: this test is flaky because map is unordered.
-// func TestMarshalUnmarshalMap(t *testing.T) {
-// 	data := map[string]map[string]any{
-// 		"Video": {
-// 			"width":      1024,
-// 			"height":     768,
-// 			"fullscreen": true,
-// 		},
-// 	}
-// 	t.Run("map", func(t *testing.T) {
-// 		const expect = "[Video]\nwidth=1024\nheight=768\nfullscreen=true\n"
-// 		testMarshal(t, expect, data)
-// 	})
-// }
+func TestMarshalUnmarshalMap(t *testing.T) {
+	data := map[string]map[string]any{
+		"Video": {
+			"width":      1024,
+			"height":     768,
+			"fullscreen": true,
+		},
+		"Audio": {
+			"volume": 0.5,
+		},
+	}
+	t.Run("map", func(t *testing.T) {
+		const expect = "[Audio]\nvolume=0.5\n" +
+			"[Video]\nfullscreen=true\nheight=768\nwidth=1024\n"
+		testMarshal(t, expect, data)
+	})
+	t.Run("key order", func(t *testing.T) {
+		const expect = "[Video]\nwidth=1024\nheight=768\nfullscreen=true\n" +
+			"[Audio]\nvolume=0.5\n"
+		buf := bytes.Buffer{}
+		e := ini.NewEncoder(&buf).KeyOrder(func(a, b string) int {
+			return strings.Compare(b, a)
+		})
+		if err := e.Encode(data); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != expect {
+			t.Errorf("unexpected encoder output\nexpect:\n%s\ngot:\n%s", expect, buf.String())
+		}
+	})
+	t.Run("unmarshal", func(t *testing.T) {
+		b, err := ini.Marshal(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := ini.UnmarshalMap(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m["Video"]["width"] != int64(1024) || m["Audio"]["volume"] != 0.5 {
+			t.Errorf("unexpected map: %#v", m)
+		}
+	})
+}
 
 func TestMarshalUnmarshal(t *testing.T) {
 	type A struct {
@@ -83,3 +115,250 @@ func TestMarshalUnmarshal(t *testing.T) {
 		)
 	}
 }
+
+func TestUnmarshalNilPointers(t *testing.T) {
+	type Section struct {
+		X *int `ini:"x"`
+		Y int  `ini:"y"`
+	}
+	var file struct {
+		A *Section
+		B *Section
+	}
+	if err := ini.Unmarshal([]byte("[A]\nx=1\n"), &file); err != nil {
+		t.Fatal(err)
+	}
+	if file.A == nil || file.A.X == nil || *file.A.X != 1 {
+		t.Errorf("section was not allocated: %+v", file.A)
+	}
+	if file.B != nil {
+		t.Errorf("missing section was allocated: %+v", file.B)
+	}
+}
+
+func TestUnmarshalDefaults(t *testing.T) {
+	type Server struct {
+		Host  string   `ini:"host,default=localhost"`
+		Port  int      `ini:"port" default:"8080"`
+		Peers []string `ini:"peers" default:"a,b"`
+		Debug *bool    `ini:"debug,default=true"`
+	}
+	type file struct {
+		Server Server
+		Backup *Server
+	}
+
+	var f file
+	if err := ini.Unmarshal([]byte("[Server]\nport=9000\n"), &f); err != nil {
+		t.Fatal(err)
+	}
+	expect := Server{
+		Host:  "localhost",
+		Port:  9000,
+		Peers: []string{"a", "b"},
+		Debug: f.Server.Debug,
+	}
+	if !reflect.DeepEqual(f.Server, expect) || f.Server.Debug == nil || !*f.Server.Debug {
+		t.Errorf("unexpected section: %+v", f.Server)
+	}
+	if f.Backup != nil {
+		t.Errorf("missing section was allocated: %+v", f.Backup)
+	}
+
+	t.Run("omitempty", func(t *testing.T) {
+		type Section struct {
+			Port int `ini:"port,omitempty,default=8080"`
+		}
+		type file struct {
+			S Section
+		}
+		testMarshal(t, "[S]\n", file{S: Section{Port: 8080}})
+		testMarshal(t, "[S]\nport=9000\n", file{S: Section{Port: 9000}})
+		testMarshal(t, "[S]\nport=0\n", file{S: Section{Port: 0}})
+
+		type pointers struct {
+			S struct {
+				Port *int `ini:"port,omitempty,default=8080"`
+			}
+		}
+		testMarshal(t, "[S]\n", pointers{})
+		testMarshal(t, "[S]\n", &pointers{})
+
+		zero := 0
+		value := pointers{}
+		value.S.Port = &zero
+		testMarshal(t, "[S]\nport=0\n", &value)
+	})
+
+	t.Run("duplicate empty default", func(t *testing.T) {
+		var f struct {
+			S struct {
+				Name string `ini:"name,default=" default:"x"`
+			}
+		}
+		if err := ini.Unmarshal([]byte("[S]\n"), &f); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestUnmarshalInheritance(t *testing.T) {
+	type Server struct {
+		Host    string `ini:"host"`
+		Port    int    `ini:"port"`
+		Timeout int    `ini:"timeout"`
+	}
+	type file struct {
+		Base    Server `ini:"base"`
+		Staging Server `ini:"staging"`
+		Prod    Server `ini:"prod"`
+	}
+
+	const data = `
+[DEFAULT]
+timeout=30
+port=1
+
+[base]
+host=localhost
+port=8080
+
+[prod : staging]
+host=example.com
+
+[staging:base]
+timeout=10
+`
+
+	var f file
+	if err := ini.Unmarshal([]byte(data), &f); err != nil {
+		t.Fatal(err)
+	}
+	expect := file{
+		Base:    Server{"localhost", 8080, 30},
+		Staging: Server{"localhost", 8080, 10},
+		Prod:    Server{"example.com", 8080, 10},
+	}
+	if f != expect {
+		t.Errorf("unexpected value: %+v", f)
+	}
+
+	t.Run("untyped", func(t *testing.T) {
+		m, err := ini.UnmarshalMap([]byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := m["DEFAULT"]; ok || m["prod"]["timeout"] != int64(10) {
+			t.Errorf("unexpected map: %v", m)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := map[string]string{
+			"[a : b]\n":                    "unknown parent section 'b' of section 'a' at 1:1",
+			"[a : b]\n[b : a]\n":           "inheritance cycle in section 'a' at 1:1",
+			"[a : b]\n[b]\n[c]\n[a : c]\n": "conflicting parents of section 'a' at 4:1",
+			"[ : b]\n":                     "expected 'section : parent' at 1:1",
+		}
+		for data, expect := range tests {
+			var f file
+			err := ini.Unmarshal([]byte(data), &f)
+			if err == nil || err.Error() != expect {
+				t.Errorf("unexpected error for %q\nexpect: %s\ngot:    %v", data, expect, err)
+			}
+		}
+	})
+}
+
+func TestUnmarshalRemain(t *testing.T) {
+	type Server struct {
+		Host  string            `ini:"host"`
+		Extra map[string]string `ini:",remain"`
+	}
+	type Log struct {
+		Level string `ini:"level"`
+	}
+	type file struct {
+		Server  Server                       `ini:"server"`
+		Log     *Log                         `ini:"log"`
+		Unknown map[string]map[string]string `ini:",remain"`
+	}
+
+	const data = "[server]\nhost=localhost\nport=8080\nmode=fast\n" +
+		"[log]\nlevel=info\nfile=app.log\n" +
+		"[cache]\nsize=10\n[auth]\nuser=admin\n"
+
+	var f file
+	if err := ini.Unmarshal([]byte(data), &f); err != nil {
+		t.Fatal(err)
+	}
+	expect := file{
+		Server: Server{
+			Host:  "localhost",
+			Extra: map[string]string{"port": "8080", "mode": "fast"},
+		},
+		Log: &Log{Level: "info"},
+		Unknown: map[string]map[string]string{
+			"cache": {"size": "10"},
+			"auth":  {"user": "admin"},
+		},
+	}
+	if !reflect.DeepEqual(f, expect) {
+		t.Errorf("unexpected value: %+v", f)
+	}
+
+	testMarshal(
+		t,
+		"[server]\nhost='localhost'\nmode='fast'\nport='8080'\n"+
+			"[log]\nlevel='info'\n"+
+			"[auth]\nuser='admin'\n[cache]\nsize='10'\n",
+		f,
+	)
+
+	t.Run("inherited keys", func(t *testing.T) {
+		var f struct {
+			Server Server `ini:"server"`
+			Backup Server `ini:"backup"`
+		}
+		const data = "[DEFAULT]\ntimeout=1\nhost=localhost\n" +
+			"[server]\nport=8080\n[backup : server]\nmode=slow\n"
+		if err := ini.Unmarshal([]byte(data), &f); err != nil {
+			t.Fatal(err)
+		}
+		expect := Server{Host: "localhost", Extra: map[string]string{"port": "8080"}}
+		if !reflect.DeepEqual(f.Server, expect) {
+			t.Errorf("unexpected section: %+v", f.Server)
+		}
+		expect = Server{Host: "localhost", Extra: map[string]string{"mode": "slow"}}
+		if !reflect.DeepEqual(f.Backup, expect) {
+			t.Errorf("unexpected section: %+v", f.Backup)
+		}
+	})
+
+	t.Run("invalid types", func(t *testing.T) {
+		var section struct {
+			S struct {
+				Extra map[string][]int `ini:",remain"`
+				Bad   []string         `ini:",remain"`
+			}
+		}
+		err := ini.Unmarshal([]byte(""), &section)
+		const expect = "type of field 'Bad' in type 'struct { Extra map[string][]int " +
+			"\"ini:\\\",remain\\\"\"; Bad []string \"ini:\\\",remain\\\"\" }' " +
+			"with 'remain' flag must be map[string]T"
+		if err == nil || err.Error() != expect {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		var root struct {
+			Unknown map[string]string `ini:",remain"`
+		}
+		err = ini.Unmarshal([]byte(""), &root)
+		const expectRoot = "type of field 'Unknown' in type 'struct { Unknown " +
+			"map[string]string \"ini:\\\",remain\\\"\" }' with 'remain' flag " +
+			"must be map[string]map[string]T"
+		if err == nil || err.Error() != expectRoot {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
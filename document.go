@@ -0,0 +1,313 @@
+package ini
+
+import (
+	"fmt"
+	"slices"
+)
+
+// document is a parsed INI file that is not bound to any Go value yet.
+type document struct {
+	sections []*docSection
+}
+
+// docSection is a section of the parsed INI file. Sections with the same
+// name are merged into one.
+type docSection struct {
+	name   string
+	parent string // Name of the section to inherit keys from.
+	keys   []docKey
+	file   string
+	line   uint32
+	column uint32
+}
+
+// docKey is a key-value pair of the parsed INI file.
+type docKey struct {
+	name      string
+	value     docValue
+	file      string
+	line      uint32
+	column    uint32
+	inherited string // Name of the section the key is inherited from.
+}
+
+// position returns the position of the key in the file.
+func (k docKey) position() string {
+	if k.file != "" {
+		return fmt.Sprintf("%s:%d:%d", k.file, k.line, k.column)
+	}
+	return fmt.Sprintf("%d:%d", k.line, k.column)
+}
+
+// position returns the position of the section header in the file.
+func (s *docSection) position() string {
+	if s.file != "" {
+		return fmt.Sprintf("%s:%d:%d", s.file, s.line, s.column)
+	}
+	return fmt.Sprintf("%d:%d", s.line, s.column)
+}
+
+// docValue is a value of the parsed INI file.
+type docValue struct {
+	text   string // Unquoted text, list items are joined with a comma.
+	quoted []bool // Whether each item of the value is a quoted string.
+	list   bool   // Whether the value consists of several items.
+	raw    string // Value as written in the file, without a comment.
+}
+
+// isQuoted reports whether the item of the value at index i is a quoted
+// string.
+func (v docValue) isQuoted(i int) bool {
+	return i < len(v.quoted) && v.quoted[i]
+}
+
+// section looks for a section in the document.
+func (doc *document) section(name string) *docSection {
+	for _, section := range doc.sections {
+		if section.name == name {
+			return section
+		}
+	}
+	return nil
+}
+
+// addSection returns the section with the name provided, creating it
+// if it does not exist yet.
+func (doc *document) addSection(name, file string, line, column uint32) *docSection {
+	if section := doc.section(name); section != nil {
+		return section
+	}
+	section := &docSection{name: name, file: file, line: line, column: column}
+	doc.sections = append(doc.sections, section)
+	return section
+}
+
+// addHeader returns the section written under the header '[name : parent]',
+// or '[name]' if parent is empty. The last section of the document is
+// returned if it is written under the same header, a new one is added
+// otherwise. Sections of different headers are merged by [document.inherit]
+// in the order they are added.
+func (doc *document) addHeader(name, parent, file string, line, column uint32) *docSection {
+	if n := len(doc.sections); n > 0 {
+		last := doc.sections[n-1]
+		if last.name == name && last.parent == parent {
+			return last
+		}
+	}
+	section := &docSection{
+		name:   name,
+		parent: parent,
+		file:   file,
+		line:   line,
+		column: column,
+	}
+	doc.sections = append(doc.sections, section)
+	return section
+}
+
+// key looks for a key in the section.
+func (s *docSection) key(name string) (docKey, bool) {
+	for _, key := range s.keys {
+		if key.name == name {
+			return key, true
+		}
+	}
+	return docKey{}, false
+}
+
+// set adds the key to the section, a key with the same name is replaced.
+func (s *docSection) set(key docKey) {
+	for i := range s.keys {
+		if s.keys[i].name == key.name {
+			s.keys[i] = key
+			return
+		}
+	}
+	s.keys = append(s.keys, key)
+}
+
+// defaultSection is the name of the section whose keys fall back into
+// every other section.
+const defaultSection = "DEFAULT"
+
+// inheritOptions controls how headers are resolved by [document.inherit].
+type inheritOptions struct {
+	profile  string   // Selected profile, see [Decoder.Profile].
+	profiles []string // Declared profiles, see [Decoder.Profiles].
+
+	// normalize is applied to names of sections, parents and keys before
+	// they are compared, see [Decoder.NormalizeNames]. Names are compared
+	// as is if it is nil.
+	normalize func(name string) string
+}
+
+// equal reports whether the names are equal after normalization.
+func (opts *inheritOptions) equal(a, b string) bool {
+	if opts.normalize == nil {
+		return a == b
+	}
+	return opts.normalize(a) == opts.normalize(b)
+}
+
+// isProfile reports whether the name is a declared or selected profile.
+func (opts *inheritOptions) isProfile(name string) bool {
+	if opts.profile != "" && opts.equal(opts.profile, name) {
+		return true
+	}
+	return slices.ContainsFunc(opts.profiles, func(profile string) bool {
+		return opts.equal(profile, name)
+	})
+}
+
+// find looks for a section of the document, comparing names as opts do.
+func (doc *document) find(name string, opts *inheritOptions) *docSection {
+	for _, section := range doc.sections {
+		if opts.equal(section.name, name) {
+			return section
+		}
+	}
+	return nil
+}
+
+// inherit merges sections written under different headers, then copies
+// keys of parent sections and keys of the default section into sections
+// that do not set them. The default section is removed from the document.
+//
+// A header '[name : parent]' whose parent is a profile is a profile of
+// the section: keys of the selected profile overlay keys of the section,
+// other profiles are removed.
+func (doc *document) inherit(opts inheritOptions) error {
+	if err := doc.merge(&opts); err != nil {
+		return err
+	}
+
+	resolved := map[*docSection]bool{}
+	visiting := map[*docSection]bool{}
+
+	var resolve func(s *docSection) error
+	resolve = func(s *docSection) error {
+		if s.parent == "" || resolved[s] {
+			return nil
+		}
+		if visiting[s] {
+			return fmt.Errorf(
+				"inheritance cycle in section '%s' at %s",
+				s.name,
+				s.position(),
+			)
+		}
+
+		parent := doc.find(s.parent, &opts)
+		if parent == nil {
+			return fmt.Errorf(
+				"unknown parent section '%s' of section '%s' at %s",
+				s.parent,
+				s.name,
+				s.position(),
+			)
+		}
+
+		visiting[s] = true
+		if err := resolve(parent); err != nil {
+			return err
+		}
+		visiting[s] = false
+		resolved[s] = true
+
+		s.fallback(parent, &opts)
+		return nil
+	}
+
+	for _, section := range doc.sections {
+		if err := resolve(section); err != nil {
+			return err
+		}
+	}
+
+	if defaults := doc.find(defaultSection, &opts); defaults != nil {
+		for _, section := range doc.sections {
+			if section != defaults {
+				section.fallback(defaults, &opts)
+			}
+		}
+		doc.sections = slices.DeleteFunc(doc.sections, func(s *docSection) bool {
+			return s == defaults
+		})
+	}
+
+	return nil
+}
+
+// fallback adds keys of the parent section that are not set in
+// the section.
+func (s *docSection) fallback(parent *docSection, opts *inheritOptions) {
+	for _, key := range parent.keys {
+		present := slices.ContainsFunc(s.keys, func(k docKey) bool {
+			return opts.equal(k.name, key.name)
+		})
+		if present {
+			continue
+		}
+		if key.inherited == "" {
+			key.inherited = parent.name
+		}
+		s.keys = append(s.keys, key)
+	}
+}
+
+// merge merges sections with the same name, see [document.inherit].
+func (doc *document) merge(opts *inheritOptions) error {
+	var overlays []*docSection
+	sections := make([]*docSection, 0, len(doc.sections))
+
+	index := func(name string) int {
+		return slices.IndexFunc(sections, func(section *docSection) bool {
+			return opts.equal(section.name, name)
+		})
+	}
+
+	for _, s := range doc.sections {
+		if s.parent != "" && opts.isProfile(s.parent) {
+			if opts.profile != "" && opts.equal(s.parent, opts.profile) {
+				overlays = append(overlays, s)
+			}
+			continue
+		}
+
+		i := index(s.name)
+		if i < 0 {
+			sections = append(sections, s)
+			continue
+		}
+
+		section := sections[i]
+		if s.parent != "" {
+			if section.parent != "" && !opts.equal(section.parent, s.parent) {
+				return fmt.Errorf(
+					"conflicting parents of section '%s' at %s",
+					s.name,
+					s.position(),
+				)
+			}
+			section.parent = s.parent
+		}
+		for _, key := range s.keys {
+			section.set(key)
+		}
+	}
+
+	for _, overlay := range overlays {
+		i := index(overlay.name)
+		if i < 0 {
+			overlay.parent = ""
+			sections = append(sections, overlay)
+			continue
+		}
+		for _, key := range overlay.keys {
+			sections[i].set(key)
+		}
+	}
+
+	doc.sections = sections
+	return nil
+}
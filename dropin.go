@@ -0,0 +1,115 @@
+package ini
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"reflect"
+)
+
+// Origins maps names of sections and keys to the names of the files
+// or other sources that set them last. Names are matched with the names of
+// the decoded value as by [Decoder.Decode], keys set by environment
+// variables report the name of the variable prefixed with '$'.
+type Origins map[string]map[string]string
+
+// File returns the name of the file or another source that set the key
+// last, or an empty string if the key is not set.
+func (o Origins) File(section, key string) string {
+	return o[section][key]
+}
+
+// DecodeDropIns deserializes the INI file named name from fsys and its
+// drop-in files into a Go value. Drop-in files are the files of the
+// directory name+".d" with the same extension as the base file, for
+// example:
+//
+//	etc/app.conf
+//	etc/app.conf.d/10-network.conf
+//	etc/app.conf.d/20-logging.conf
+//
+// Drop-in files are read in lexical order after the base file and are
+// merged key by key: a key set by a later file replaces the same key of
+// an earlier one, other keys of the section are kept. The directory may
+// be missing. Include directives, described by [DefaultIncludeOptions],
+// are resolved in every file.
+//
+// The returned origins report which file set each key last.
+//
+// More information can be found in the [Unmarshal] function documentation.
+func DecodeDropIns(fsys fs.FS, name string, value any) (Origins, error) {
+	return (&Decoder{}).DecodeDropIns(fsys, name, value)
+}
+
+// DecodeDropIns is like the [DecodeDropIns] function, but uses the settings
+// of the decoder. Its input is not read. Include directives described by
+// [DefaultIncludeOptions] are resolved if [Decoder.Includes] is not set.
+func (d *Decoder) DecodeDropIns(fsys fs.FS, name string, value any) (Origins, error) {
+	settings := *d
+	d = &settings
+	if d.fsys == nil {
+		d.Includes(fsys, DefaultIncludeOptions)
+	}
+
+	if v := reflect.ValueOf(value); !isUntypedTarget(v) {
+		// Report unsupported values before reading the files.
+		if _, err := d.tree.sectionsOf(value); err != nil {
+			return nil, err
+		}
+	}
+
+	files := []string{name}
+	dir := name + ".d"
+
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if ok, _ := path.Match("*"+path.Ext(name), entry.Name()); ok {
+			files = append(files, path.Join(dir, entry.Name()))
+		}
+	}
+
+	doc := &document{}
+	for _, file := range files {
+		b, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		d.file = file
+		d.init(b)
+		if err := d.scanInto(doc, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := doc.inherit(d.inheritOptions()); err != nil {
+		return nil, err
+	}
+
+	origins := Origins{}
+	if err := d.decodeDocument(doc, value, nil, origins); err != nil {
+		return origins, err
+	}
+	return origins, nil
+}
+
+// collect adds the origins of the keys of the document. It does nothing
+// if the origins are nil.
+func (o Origins) collect(doc *document) {
+	if o == nil {
+		return
+	}
+	for _, section := range doc.sections {
+		keys := make(map[string]string, len(section.keys))
+		for _, key := range section.keys {
+			keys[key.name] = key.file
+		}
+		o[section.name] = keys
+	}
+}
@@ -0,0 +1,109 @@
+package ini_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/saffage/go-ini"
+)
+
+func TestDecodeDropIns(t *testing.T) {
+	type Config struct {
+		Server struct {
+			Host string `ini:"host"`
+			Port int    `ini:"port"`
+		} `ini:"server"`
+		Log struct {
+			Level string `ini:"level"`
+		} `ini:"log"`
+	}
+
+	fsys := fstest.MapFS{
+		"etc/app.conf": {Data: []byte(
+			"[server]\nhost=localhost\nport=80\n[log]\nlevel=info\n",
+		)},
+		"etc/app.conf.d/20-port.conf": {Data: []byte("[server]\nport=8080\n")},
+		"etc/app.conf.d/10-port.conf": {Data: []byte("[server]\nport=8000\n")},
+		"etc/app.conf.d/30-log.conf":  {Data: []byte("[log]\nlevel=debug\n")},
+		"etc/app.conf.d/99-log.ini":   {Data: []byte("[log]\nlevel=trace\n")},
+		"etc/app.conf.d/sub/x.conf":   {Data: []byte("[log]\nlevel=trace\n")},
+		"etc/other.conf":              {Data: []byte("[server]\nhost=example.com\n")},
+		"etc/broken.conf":             {Data: []byte("[server]\n")},
+		"etc/broken.conf.d/10-x.conf": {Data: []byte("[server]\n?\n")},
+	}
+
+	var config Config
+	origins, err := ini.DecodeDropIns(fsys, "etc/app.conf", &config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Server.Host != "localhost" || config.Server.Port != 8080 || config.Log.Level != "debug" {
+		t.Errorf("unexpected config: %+v", config)
+	}
+
+	expect := map[[2]string]string{
+		{"server", "host"}:  "etc/app.conf",
+		{"server", "port"}:  "etc/app.conf.d/20-port.conf",
+		{"log", "level"}:    "etc/app.conf.d/30-log.conf",
+		{"server", "other"}: "",
+	}
+	for key, file := range expect {
+		if got := origins.File(key[0], key[1]); got != file {
+			t.Errorf("unexpected origin of %s.%s: %q", key[0], key[1], got)
+		}
+	}
+
+	t.Run("without directory", func(t *testing.T) {
+		var config Config
+		origins, err := ini.DecodeDropIns(fsys, "etc/other.conf", &config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if config.Server.Host != "example.com" || origins.File("server", "host") != "etc/other.conf" {
+			t.Errorf("unexpected config: %+v", config)
+		}
+	})
+
+	t.Run("decoder settings", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"app.conf":             {Data: []byte("[Server]\nHost=localhost\n")},
+			"app.conf.d/prod.conf": {Data: []byte("[server:prod]\nhost=${log.level}.example.com\n")},
+			"app.conf.d/zlog.conf": {Data: []byte("[log]\nlevel=debug\n")},
+		}
+		lookup := func(key string) (string, bool) {
+			return "trace", key == "APP_LOG_LEVEL"
+		}
+		var config Config
+		d := ini.NewDecoder(nil).
+			Profile("prod").
+			Interpolate(true).
+			CaseInsensitive(true).
+			EnvOverrides(true, "APP").
+			LookupEnv(lookup)
+		origins, err := d.DecodeDropIns(fsys, "app.conf", &config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if config.Server.Host != "debug.example.com" || config.Log.Level != "trace" {
+			t.Errorf("unexpected config: %+v", config)
+		}
+		if origins.File("server", "host") != "app.conf.d/prod.conf" ||
+			origins.File("log", "level") != "$APP_LOG_LEVEL" {
+			t.Errorf("unexpected origins: %v", origins)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		var config Config
+		_, err := ini.DecodeDropIns(fsys, "etc/broken.conf", &config)
+		const expect = "etc/broken.conf.d/10-x.conf: unexpected character '?' at 2:1"
+		if err == nil || err.Error() != expect {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		_, err = ini.DecodeDropIns(fsys, "etc/missing.conf", &config)
+		if err == nil {
+			t.Error("expected an error for a missing base file")
+		}
+	})
+}
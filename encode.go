@@ -8,6 +8,8 @@ import (
 	"math"
 	"reflect"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
 // Marshaler interface can be implemented to customize an INI tree
@@ -71,9 +73,50 @@ func Marshal(value any) ([]byte, error) {
 
 // Encoder writes an INI tree to the specified output.
 type Encoder struct {
-	w io.Writer
+	w    io.Writer
+	tree treeOptions
+	opts EncoderOptions
 
 	skipFieldEncodeFailure bool
+	omitEmptySections      bool
+}
+
+// EncoderOptions controls the layout of the encoded file.
+// The zero value produces a compact file, the same as [Marshal] does.
+type EncoderOptions struct {
+	// Separator is written between a key and its value,
+	// for example "=", " = " or ":". Defaults to "=".
+	Separator string
+
+	// Align pads keys with spaces so that separators of all keys
+	// in a section are in the same column.
+	Align bool
+
+	// BlankLines separates sections with an empty line.
+	BlankLines bool
+
+	// CRLF ends lines with "\r\n" instead of "\n".
+	CRLF bool
+
+	// CommentPrefix is written at the start of documentation lines and
+	// commented fields, for example ";" or "#". Defaults to ";".
+	CommentPrefix string
+
+	// Indent is written before every key and its documentation,
+	// for example "\t" or "    ".
+	Indent string
+
+	// Quote controls when and how values are quoted.
+	// Defaults to [QuoteSingle].
+	Quote QuoteStyle
+
+	// RawUTF8 writes non-ASCII characters of strings as is,
+	// instead of escaping them.
+	RawUTF8 bool
+
+	// UnicodeEscapes escapes non-ASCII characters as '\uXXXX' or
+	// '\UXXXXXXXX' instead of escaping every byte as '\xXX'.
+	UnicodeEscapes bool
 }
 
 // NewEncoder creates a new [Encoder] that writes to w.
@@ -88,6 +131,30 @@ func (e *Encoder) SkipFieldEncodeFailure(flag bool) *Encoder {
 	return e
 }
 
+// OmitEmptySections allows the encoder to skip every section whose fields
+// are all omitted or have zero values, as if the section had
+// the 'omitempty' flag.
+func (e *Encoder) OmitEmptySections(flag bool) *Encoder {
+	e.omitEmptySections = flag
+	return e
+}
+
+// KeyOrder sets the function used to order sections and keys built from
+// maps. It must return a negative number when a < b, a positive number
+// when a > b and zero when a == b, see [strings.Compare].
+//
+// By default, map sections and keys are sorted in lexical order.
+func (e *Encoder) KeyOrder(cmp func(a, b string) int) *Encoder {
+	e.tree.keyOrder = cmp
+	return e
+}
+
+// Options sets the layout of the encoded file.
+func (e *Encoder) Options(opts EncoderOptions) *Encoder {
+	e.opts = opts
+	return e
+}
+
 // Reset resets the encoder to write to w, keeping all of its settings.
 func (e *Encoder) Reset(w io.Writer) *Encoder {
 	e.w = w
@@ -98,7 +165,7 @@ func (e *Encoder) Reset(w io.Writer) *Encoder {
 //
 // More information can be found in the [Marshal] function documentation.
 func (e *Encoder) Encode(data any) error {
-	sections, err := SectionsOf(data)
+	sections, err := e.tree.sectionsOf(data)
 	if err != nil {
 		return err
 	}
@@ -118,82 +185,171 @@ func (e *Encoder) Encode(data any) error {
 	return nil
 }
 
+// encodedField is a field with its already encoded value.
+type encodedField struct {
+	Field
+	value []byte
+}
+
 func (e *Encoder) section(buf *bytes.Buffer, section Section) error {
-	buf.WriteByte('[')
-	buf.WriteString(section.Name)
-	buf.WriteByte(']')
-	buf.WriteByte('\n')
+	if section.null {
+		return nil
+	}
+
+	fields := make([]encodedField, 0, len(section.Fields))
+	width := 0
+	empty := true
 
 	for _, field := range section.Fields {
-		if err := e.field(buf, field); err != nil && !e.skipFieldEncodeFailure {
+		b, err := field.marshalText(&e.opts)
+		if err != nil {
+			if e.skipFieldEncodeFailure {
+				continue
+			}
 			return err
 		}
+
+		if b == nil && !field.Commented {
+			continue
+		}
+
+		fields = append(fields, encodedField{field, b})
+		width = max(width, e.keyWidth(field))
+		empty = empty && isZeroOrEmpty(field.Value)
+	}
+
+	if empty && (section.OmitEmpty || e.omitEmptySections) {
+		return nil
+	}
+
+	if buf.Len() > 0 && e.opts.BlankLines {
+		e.newline(buf)
+	}
+
+	e.doc(buf, "", section.Doc)
+	buf.WriteByte('[')
+	buf.WriteString(section.Name)
+	buf.WriteByte(']')
+	e.newline(buf)
+
+	for _, field := range fields {
+		e.field(buf, field, width)
 	}
 
 	return nil
 }
 
-func (e *Encoder) field(buf *bytes.Buffer, field Field) error {
-	b, err := field.MarshalText()
-	if err != nil {
-		return err
-	}
+func (e *Encoder) field(buf *bytes.Buffer, field encodedField, width int) {
+	e.doc(buf, e.opts.Indent, field.Doc)
+	buf.WriteString(e.opts.Indent)
 
 	if field.Commented {
-		buf.WriteByte(';')
+		buf.WriteString(e.commentPrefix())
 	}
 
-	if b != nil || field.Commented {
-		buf.WriteString(field.Name)
+	buf.WriteString(field.Name)
+
+	if e.opts.Align {
+		for range width - e.keyWidth(field.Field) {
+			buf.WriteByte(' ')
+		}
+	}
+
+	if e.opts.Separator != "" {
+		buf.WriteString(e.opts.Separator)
+	} else {
 		buf.WriteByte('=')
-		buf.Write(b)
-		buf.WriteByte('\n')
 	}
 
-	return nil
+	buf.Write(field.value)
+	e.newline(buf)
+}
+
+// keyWidth returns the number of characters written before the separator.
+func (e *Encoder) keyWidth(field Field) int {
+	width := utf8.RuneCountInString(field.Name)
+	if field.Commented {
+		width += utf8.RuneCountInString(e.commentPrefix())
+	}
+	return width
+}
+
+// doc writes the documentation as comment lines.
+func (e *Encoder) doc(buf *bytes.Buffer, indent, doc string) {
+	if doc == "" {
+		return
+	}
+	for _, line := range strings.Split(doc, "\n") {
+		buf.WriteString(indent)
+		buf.WriteString(e.commentPrefix())
+		if line = strings.TrimRight(line, " \t\r"); line != "" {
+			buf.WriteByte(' ')
+			buf.WriteString(line)
+		}
+		e.newline(buf)
+	}
+}
+
+func (e *Encoder) commentPrefix() string {
+	if e.opts.CommentPrefix != "" {
+		return e.opts.CommentPrefix
+	}
+	return ";"
+}
+
+func (e *Encoder) newline(buf *bytes.Buffer) {
+	if e.opts.CRLF {
+		buf.WriteByte('\r')
+	}
+	buf.WriteByte('\n')
 }
 
 var (
 	tMarshaler        = reflect.TypeFor[Marshaler]()
 	tSectionMarshaler = reflect.TypeFor[SectionMarshaler]()
 	tTextMarshaler    = reflect.TypeFor[encoding.TextMarshaler]()
+	tSections         = reflect.TypeFor[[]Section]()
 )
 
-func encode(v reflect.Value) ([]byte, error) {
+func encode(v reflect.Value, opts *EncoderOptions) ([]byte, error) {
 	if v.Kind() == reflect.Interface {
 		v = v.Elem()
 	}
 
 	t := v.Type()
 
+	if t == tRawValue {
+		return []byte(v.Interface().(RawValue).Text), nil
+	}
+
 	if t.Implements(tTextMarshaler) {
 		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
 		if err != nil {
 			return nil, err
 		}
-		return b, err
+		return opts.quoteScalar(b), err
 	}
 
-	if v.CanAddr() && reflect.PointerTo(t).Implements(tMarshaler) {
+	if v.CanAddr() && reflect.PointerTo(t).Implements(tTextMarshaler) {
 		b, err := v.Addr().Interface().(encoding.TextMarshaler).MarshalText()
 		if err != nil {
 			return nil, err
 		}
-		return b, err
+		return opts.quoteScalar(b), err
 	}
 
 	switch v.Kind() {
 	case reflect.Bool:
 		encoded := strconv.FormatBool(v.Bool())
-		return []byte(encoded), nil
+		return opts.quoteScalar([]byte(encoded)), nil
 
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		encoded := strconv.FormatInt(v.Int(), 10)
-		return []byte(encoded), nil
+		return opts.quoteScalar([]byte(encoded)), nil
 
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		encoded := strconv.FormatUint(v.Uint(), 10)
-		return []byte(encoded), nil
+		return opts.quoteScalar([]byte(encoded)), nil
 
 	case reflect.Float32, reflect.Float64:
 		value, encoded := v.Float(), ""
@@ -206,7 +362,7 @@ func encode(v reflect.Value) ([]byte, error) {
 		} else {
 			encoded = strconv.FormatFloat(value, 'f', -1, 64)
 		}
-		return []byte(encoded), nil
+		return opts.quoteScalar([]byte(encoded)), nil
 
 	case reflect.Array, reflect.Slice:
 		buf := []byte{}
@@ -214,7 +370,7 @@ func encode(v reflect.Value) ([]byte, error) {
 			if i > 0 {
 				buf = append(buf, ',')
 			}
-			b, err := encode(v.Index(i))
+			b, err := encode(v.Index(i), opts)
 			if err != nil {
 				return nil, err
 			}
@@ -223,7 +379,7 @@ func encode(v reflect.Value) ([]byte, error) {
 		return buf, nil
 
 	case reflect.String:
-		encoded := quoteString(v.String())
+		encoded := opts.quote(v.String())
 		return []byte(encoded), nil
 
 	default:
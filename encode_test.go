@@ -1,6 +1,7 @@
 package ini_test
 
 import (
+	"bytes"
 	"reflect"
 	"testing"
 
@@ -83,3 +84,134 @@ func testMarshal(t *testing.T, expect string, data any) {
 		)
 	}
 }
+
+type docMarshalINI struct{}
+
+func (docMarshalINI) MarshalINI() (ini.Section, error) {
+	return ini.Section{
+		Doc: "Audio settings.",
+		Fields: []ini.Field{
+			{
+				Name:  "volume",
+				Value: reflect.ValueOf(50),
+				Doc:   "Volume in percents.",
+			},
+		},
+	}, nil
+}
+
+func TestMarshalDoc(t *testing.T) {
+	type VideoSettings struct {
+		Width      int  `ini:"width" comment:"Width of the window.\nIgnored in fullscreen mode."`
+		Height     int  `ini:"height,doc=Height of the window."`
+		FullScreen bool `ini:"fullscreen,omitempty,commented" comment:"Enables fullscreen."`
+		VSync      bool `ini:"vsync,omitempty" comment:"Omitted."`
+	}
+	type Settings struct {
+		Video VideoSettings `comment:"Video settings."`
+		Audio docMarshalINI
+	}
+	const expect = "; Video settings.\n[Video]\n" +
+		"; Width of the window.\n; Ignored in fullscreen mode.\nwidth=0\n" +
+		"; Height of the window.\nheight=0\n" +
+		"; Enables fullscreen.\n;fullscreen=\n" +
+		"; Audio settings.\n[Audio]\n; Volume in percents.\nvolume=50\n"
+	testMarshal(t, expect, Settings{})
+}
+
+func TestEncoderOptions(t *testing.T) {
+	type VideoSettings struct {
+		Width      int  `ini:"width" comment:"Width of the window."`
+		Height     int  `ini:"height"`
+		FullScreen bool `ini:"fullscreen,commented"`
+	}
+	type AudioSettings struct {
+		Volume int `ini:"volume"`
+	}
+	type Settings struct {
+		Video VideoSettings
+		Audio AudioSettings
+	}
+	settings := Settings{
+		Video: VideoSettings{Width: 1024, Height: 768},
+		Audio: AudioSettings{Volume: 50},
+	}
+	tests := []struct {
+		name   string
+		opts   ini.EncoderOptions
+		expect string
+	}{
+		{
+			name: "separator",
+			opts: ini.EncoderOptions{Separator: " = ", BlankLines: true},
+			expect: "[Video]\n; Width of the window.\nwidth = 1024\nheight = 768\n" +
+				";fullscreen = false\n\n[Audio]\nvolume = 50\n",
+		},
+		{
+			name: "align",
+			opts: ini.EncoderOptions{Separator: " = ", Align: true, CommentPrefix: "#"},
+			expect: "[Video]\n# Width of the window.\nwidth       = 1024\n" +
+				"height      = 768\n#fullscreen = false\n[Audio]\nvolume = 50\n",
+		},
+		{
+			name: "crlf",
+			opts: ini.EncoderOptions{Separator: ":", CRLF: true, Indent: "\t"},
+			expect: "[Video]\r\n\t; Width of the window.\r\n\twidth:1024\r\n" +
+				"\theight:768\r\n\t;fullscreen:false\r\n[Audio]\r\n\tvolume:50\r\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf := bytes.Buffer{}
+			err := ini.NewEncoder(&buf).Options(test.opts).Encode(settings)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != test.expect {
+				t.Errorf("unexpected encoder output\nexpect:\n%q\ngot:\n%q", test.expect, buf.String())
+			}
+
+			var decoded Settings
+			if err := ini.Unmarshal(buf.Bytes(), &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(decoded, settings) {
+				t.Errorf("unexpected decoded value: %+v", decoded)
+			}
+		})
+	}
+}
+
+func TestMarshalOmitEmptySections(t *testing.T) {
+	type Section struct {
+		X int `ini:"x,omitempty"`
+		Y int `ini:"y"`
+	}
+	type file struct {
+		A Section
+		B Section `ini:",omitempty"`
+		C *Section
+		D any
+	}
+	t.Run("empty", func(t *testing.T) {
+		testMarshal(t, "[A]\ny=0\n", file{})
+	})
+	t.Run("filled", func(t *testing.T) {
+		const expect = "[A]\ny=0\n[B]\ny=1\n[C]\nx=2\ny=0\n[D]\ny=3\n"
+		testMarshal(t, expect, file{
+			B: Section{Y: 1},
+			C: &Section{X: 2},
+			D: Section{Y: 3},
+		})
+	})
+	t.Run("encoder", func(t *testing.T) {
+		buf := bytes.Buffer{}
+		err := ini.NewEncoder(&buf).OmitEmptySections(true).Encode(file{C: &Section{}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != "" {
+			t.Errorf("unexpected encoder output:\n%s", buf.String())
+		}
+	})
+}
@@ -0,0 +1,207 @@
+package ini
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EnvOverrides allows the decoder to override values of the file with
+// environment variables.
+//
+// A field with the 'env' tag is overridden by the variable named in
+// the tag. If prefix is not empty, other fields are overridden by
+// the variables named PREFIX_SECTION_KEY, where the section and the key
+// are upper-cased and every character other than a letter or a digit
+// is replaced with an underscore.
+//
+// Values of the variables are used as is, without unquoting.
+func (d *Decoder) EnvOverrides(flag bool, prefix string) *Decoder {
+	d.env = flag
+	d.envPrefix = prefix
+	return d
+}
+
+// LookupEnv sets the function used to look up environment variables,
+// [os.LookupEnv] is used by default.
+func (d *Decoder) LookupEnv(lookup func(key string) (string, bool)) *Decoder {
+	d.lookupEnv = lookup
+	return d
+}
+
+func (d *Decoder) getenv(key string) (string, bool) {
+	if d.lookupEnv != nil {
+		return d.lookupEnv(key)
+	}
+	return os.LookupEnv(key)
+}
+
+// overlayEnv adds keys from the environment to the document.
+func (d *Decoder) overlayEnv(doc *document, sections []Section) {
+	for _, kv := range envKeys(sections, d.envPrefix, d.getenv) {
+		doc.addSection(kv.Section, "", 0, 0).set(docKey{
+			name:  kv.Key,
+			value: docValue{text: kv.Value},
+			file:  kv.Origin,
+		})
+	}
+}
+
+// envKeys returns the keys of fields overridden by environment variables,
+// see [Decoder.EnvOverrides].
+func envKeys(
+	sections []Section,
+	prefix string,
+	getenv func(string) (string, bool),
+) []KeyValue {
+	var keys []KeyValue
+	for _, section := range sections {
+		for _, field := range section.Fields {
+			name := field.Env
+			if name == "" && prefix != "" {
+				name = envName(prefix, section.Name, field.Name)
+			}
+			if name == "" {
+				continue
+			}
+
+			value, ok := getenv(name)
+			if !ok {
+				continue
+			}
+
+			keys = append(keys, KeyValue{
+				Section: section.Name,
+				Key:     field.Name,
+				Value:   value,
+				Origin:  "$" + name,
+			})
+		}
+	}
+	return keys
+}
+
+// envName builds the name of an environment variable for the key.
+func envName(prefix, section, key string) string {
+	name := strings.TrimSuffix(prefix, "_") + "_" + section + "_" + key
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// ExpandEnv allows the decoder to expand environment variables in values,
+// using a shell-like syntax:
+//
+//	$NAME, ${NAME}    value of the variable, empty if it is unset
+//	${NAME:-default}  default if the variable is unset or empty
+//	${NAME-default}   default if the variable is unset
+//	${NAME:+value}    value if the variable is set and not empty
+//	${NAME:?message}  error if the variable is unset or empty
+//	${NAME?message}   error if the variable is unset
+//
+// Use '$$' to write a single '$'. Expansion can be disabled for a field
+// with the 'noexpand' flag, which also disables references to other keys.
+// When combined with [Decoder.Interpolate], a '${name}' reference to
+// an existing key takes precedence.
+func (d *Decoder) ExpandEnv(flag bool) *Decoder {
+	d.expandEnv = flag
+	return d
+}
+
+// expandEnvRef expands a '${name...}' reference, where rest is the operator
+// and the word following the name of the variable.
+func (interp *interpolator) expandEnvRef(
+	section *docSection,
+	key docKey,
+	name string,
+	rest string,
+) (string, error) {
+	value, set := interp.getenv(name)
+	if rest == "" {
+		return value, nil
+	}
+
+	op, word := rest[:1], rest[1:]
+	if op == ":" && len(rest) > 1 {
+		op, word = rest[:2], rest[2:]
+		set = set && value != ""
+	}
+
+	switch op {
+	case "-", ":-":
+		if set {
+			return value, nil
+		}
+		return interp.expand(section, key, word, true)
+
+	case "+", ":+":
+		if !set {
+			return "", nil
+		}
+		return interp.expand(section, key, word, true)
+
+	case "?", ":?":
+		if set {
+			return value, nil
+		}
+		msg, err := interp.expand(section, key, word, true)
+		if err != nil {
+			return "", err
+		}
+		if msg == "" {
+			msg = "variable is not set"
+		}
+		return "", fmt.Errorf(
+			"%s: %s in key '%s' at %s",
+			name,
+			msg,
+			key.name,
+			key.position(),
+		)
+
+	default:
+		return "", errReference("invalid reference", "${"+name+rest+"}", key)
+	}
+}
+
+// envNameLen returns the length of the environment variable name at
+// the start of the string.
+func envNameLen(s string) int {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' ||
+			i > 0 && c >= '0' && c <= '9') {
+			return i
+		}
+	}
+	return len(s)
+}
+
+// matchingBrace returns the index of the brace that closes the '${'
+// at the start of the string, or -1.
+func matchingBrace(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "${"):
+			depth++
+			i++
+
+		case s[i] == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,122 @@
+package ini_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/saffage/go-ini"
+)
+
+func TestEnvOverrides(t *testing.T) {
+	type Database struct {
+		Host string `ini:"host" env:"DB_HOST"`
+		Port int    `ini:"port"`
+		User string `ini:"user,required"`
+	}
+	type Config struct {
+		Database Database `ini:"db"`
+	}
+	env := map[string]string{
+		"DB_HOST":     "db.internal",
+		"APP_DB_PORT": "5433",
+		"APP_DB_USER": "admin",
+	}
+	lookup := func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+	data := []byte("[db]\nhost=localhost\nport=5432\n")
+
+	t.Run("disabled", func(t *testing.T) {
+		var config Config
+		d := ini.NewDecoder(bytes.NewReader(data)).LookupEnv(lookup)
+		if err := d.Decode(&config); err == nil {
+			t.Errorf("expected missing key error, got %+v", config)
+		}
+	})
+	t.Run("tags", func(t *testing.T) {
+		var config Config
+		d := ini.NewDecoder(bytes.NewReader(append(data, "user=root\n"...))).
+			EnvOverrides(true, "").
+			LookupEnv(lookup)
+		if err := d.Decode(&config); err != nil {
+			t.Fatal(err)
+		}
+		expect := Database{Host: "db.internal", Port: 5432, User: "root"}
+		if config.Database != expect {
+			t.Errorf("unexpected section: %+v", config.Database)
+		}
+	})
+	t.Run("prefix", func(t *testing.T) {
+		var config Config
+		d := ini.NewDecoder(bytes.NewReader(data)).
+			EnvOverrides(true, "APP").
+			LookupEnv(lookup)
+		if err := d.Decode(&config); err != nil {
+			t.Fatal(err)
+		}
+		expect := Database{Host: "db.internal", Port: 5433, User: "admin"}
+		if config.Database != expect {
+			t.Errorf("unexpected section: %+v", config.Database)
+		}
+	})
+}
+
+func TestExpandEnv(t *testing.T) {
+	type Section struct {
+		Home    string `ini:"home"`
+		DB      string `ini:"db"`
+		Mode    string `ini:"mode"`
+		Pattern string `ini:"pattern,noexpand"`
+		Path    string `ini:"path"`
+	}
+	type Config struct {
+		App Section `ini:"app"`
+	}
+	env := map[string]string{
+		"HOME":  "/home/user",
+		"EMPTY": "",
+		"X":     "x",
+	}
+	lookup := func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+	const data = `[app]
+home=$HOME/app
+db=${DB_PASSWORD:-secret}
+mode=${EMPTY-set}${EMPTY:+x}
+pattern=$HOME/${X}
+path=${home}/$${HOME}
+`
+	var config Config
+	d := ini.NewDecoder(bytes.NewReader([]byte(data))).
+		ExpandEnv(true).
+		Interpolate(true).
+		LookupEnv(lookup)
+	if err := d.Decode(&config); err != nil {
+		t.Fatal(err)
+	}
+	expect := Section{
+		Home:    "/home/user/app",
+		DB:      "secret",
+		Mode:    "",
+		Pattern: "$HOME/${X}",
+		Path:    "/home/user/app/${HOME}",
+	}
+	if config.App != expect {
+		t.Errorf("unexpected section\nexpect: %+v\ngot:    %+v", expect, config.App)
+	}
+
+	t.Run("required", func(t *testing.T) {
+		m := map[string]any{}
+		d := ini.NewDecoder(bytes.NewReader([]byte("[a]\nx=${TOKEN:?token is required}\n"))).
+			ExpandEnv(true).
+			LookupEnv(lookup)
+		err := d.Decode(&m)
+		const expect = "TOKEN: token is required in key 'x' at 2:1"
+		if err == nil || err.Error() != expect {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
@@ -4,14 +4,29 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
 )
 
 type flags struct {
-	key       string
-	inline    bool
-	omitempty bool
-	commented bool
+	key        string
+	doc        string
+	oneof      []string
+	def        string
+	hasDef     bool
+	env        string
+	inline     bool
+	omitempty  bool
+	commented  bool
+	required   bool
+	noexpand   bool
+	remain     bool
+	aliases    []string
+	deprecated string
+	rules      rules
+
+	alloc func() // See [Field.alloc].
 }
 
 func parseTag(t reflect.Type, field reflect.StructField) (flags, error) {
@@ -25,7 +40,15 @@ func parseTag(t reflect.Type, field reflect.StructField) (flags, error) {
 		}
 
 		for _, flag := range strings.Split(rest, ",") {
-			flag = strings.TrimSpace(flag)
+			flag, arg, hasArg := strings.Cut(strings.TrimSpace(flag), "=")
+
+			takesArg, known := knownFlags[flag]
+			if !known {
+				return flags, errUnknownFlag(flag, field.Name, t.String())
+			}
+			if hasArg != takesArg {
+				return flags, errFlagArgument(flag, field.Name, t.String(), hasArg)
+			}
 
 			switch flag {
 			case "inline":
@@ -46,15 +69,144 @@ func parseTag(t reflect.Type, field reflect.StructField) (flags, error) {
 				}
 				flags.commented = true
 
-			default:
-				return flags, errUnknownFlag(flag, field.Name, t.String())
+			case "doc":
+				if flags.doc != "" {
+					return flags, errDuplicateFlag(flag, field.Name, t.String())
+				}
+				flags.doc = strings.TrimSpace(arg)
+
+			case "default":
+				if flags.hasDef {
+					return flags, errDuplicateFlag(flag, field.Name, t.String())
+				}
+				flags.def, flags.hasDef = arg, true
+
+			case "required":
+				if flags.required {
+					return flags, errDuplicateFlag(flag, field.Name, t.String())
+				}
+				flags.required = true
+
+			case "noexpand":
+				if flags.noexpand {
+					return flags, errDuplicateFlag(flag, field.Name, t.String())
+				}
+				flags.noexpand = true
+
+			case "remain":
+				if flags.remain {
+					return flags, errDuplicateFlag(flag, field.Name, t.String())
+				}
+				flags.remain = true
+
+			case "min", "max":
+				bound := &flags.rules.min
+				if flag == "max" {
+					bound = &flags.rules.max
+				}
+				if *bound != nil {
+					return flags, errDuplicateFlag(flag, field.Name, t.String())
+				}
+				x, err := strconv.ParseFloat(arg, 64)
+				if err != nil {
+					return flags, errInvalidFlag(flag, field.Name, t.String(), err)
+				}
+				*bound = &x
+
+			case "len":
+				if flags.rules.length != nil {
+					return flags, errDuplicateFlag(flag, field.Name, t.String())
+				}
+				x, err := strconv.Atoi(arg)
+				if err != nil {
+					return flags, errInvalidFlag(flag, field.Name, t.String(), err)
+				}
+				flags.rules.length = &x
+
+			case "regex":
+				if flags.rules.regex != nil {
+					return flags, errDuplicateFlag(flag, field.Name, t.String())
+				}
+				re, err := regexp.Compile(arg)
+				if err != nil {
+					return flags, errInvalidFlag(flag, field.Name, t.String(), err)
+				}
+				flags.rules.regex = re
+
+			case "oneof":
+				if flags.oneof != nil {
+					return flags, errDuplicateFlag(flag, field.Name, t.String())
+				}
+				flags.oneof = strings.Fields(arg)
 			}
 		}
 	}
 
+	if comment, ok := field.Tag.Lookup("comment"); ok {
+		if flags.doc != "" {
+			return flags, errDuplicateFlag("doc", field.Name, t.String())
+		}
+		flags.doc = comment
+	}
+
+	if expr, ok := field.Tag.Lookup("regex"); ok {
+		if flags.rules.regex != nil {
+			return flags, errDuplicateFlag("regex", field.Name, t.String())
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return flags, errInvalidFlag("regex", field.Name, t.String(), err)
+		}
+		flags.rules.regex = re
+	}
+
+	if env, ok := field.Tag.Lookup("env"); ok {
+		flags.env = strings.TrimSpace(env)
+	}
+
+	if aliases, ok := field.Tag.Lookup("alias"); ok {
+		for _, alias := range strings.Split(aliases, ",") {
+			if alias = strings.TrimSpace(alias); alias != "" {
+				flags.aliases = append(flags.aliases, alias)
+			}
+		}
+	}
+
+	if msg, ok := field.Tag.Lookup("deprecated"); ok {
+		flags.deprecated = strings.TrimSpace(msg)
+		if flags.deprecated == "" {
+			flags.deprecated = "deprecated"
+		}
+	}
+
+	if def, ok := field.Tag.Lookup("default"); ok {
+		if flags.hasDef {
+			return flags, errDuplicateFlag("default", field.Name, t.String())
+		}
+		flags.def, flags.hasDef = def, true
+	}
+
 	return flags, nil
 }
 
+// knownFlags maps every supported flag to whether it is written
+// as 'flag=value'.
+var knownFlags = map[string]bool{
+	"inline":    false,
+	"omitempty": false,
+	"commented": false,
+	"doc":       true,
+	"oneof":     true,
+	"default":   true,
+	"required":  false,
+	"noexpand":  false,
+	"remain":    false,
+	"min":       true,
+	"max":       true,
+	"len":       true,
+	"regex":     true,
+}
+
 func errUnknownFlag(tag, field, t string) error {
 	return fmt.Errorf(
 		"unknown flag '%s' for field '%s' in type '%s'",
@@ -72,3 +224,30 @@ func errDuplicateFlag(tag, field, t string) error {
 		t,
 	)
 }
+
+func errInvalidFlag(tag, field, t string, err error) error {
+	return fmt.Errorf(
+		"invalid value of flag '%s' for field '%s' in type '%s': %w",
+		tag,
+		field,
+		t,
+		err,
+	)
+}
+
+func errFlagArgument(tag, field, t string, hasArg bool) error {
+	if hasArg {
+		return fmt.Errorf(
+			"flag '%s' for field '%s' in type '%s' does not take a value",
+			tag,
+			field,
+			t,
+		)
+	}
+	return fmt.Errorf(
+		"flag '%s' for field '%s' in type '%s' requires a value",
+		tag,
+		field,
+		t,
+	)
+}
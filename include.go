@@ -0,0 +1,187 @@
+package ini
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+)
+
+// IncludeOptions describes how include directives are written.
+//
+// An included file is decoded as if it was written in place of
+// the directive: keys before its first section header belong to
+// the section the directive is written in.
+type IncludeOptions struct {
+	// Key is the name of a key whose value is a path of the file to
+	// include, for example "include". Empty disables such keys.
+	Key string
+
+	// Directive is a line prefix followed by a path of the file to include,
+	// for example "!include". Empty disables the directive.
+	Directive string
+
+	// DirDirective is a line prefix followed by a path of the directory
+	// whose files are included in lexical order, for example "!includedir".
+	// Empty disables the directive.
+	DirDirective string
+
+	// DirPattern filters the files of included directories, see
+	// [path.Match]. Empty includes every file.
+	DirPattern string
+
+	// MaxDepth limits the nesting of included files.
+	// Zero means the default limit of 10.
+	MaxDepth int
+}
+
+// DefaultIncludeOptions are the include directives used by [DecodeFS].
+var DefaultIncludeOptions = IncludeOptions{
+	Key:          "include",
+	Directive:    "!include",
+	DirDirective: "!includedir",
+	DirPattern:   "*.ini",
+}
+
+// DecodeFS deserializes the INI file named name from fsys into a Go value.
+// Include directives of the file, described by [DefaultIncludeOptions],
+// are resolved relative to the including file.
+//
+// More information can be found in the [Unmarshal] function documentation.
+func DecodeFS(fsys fs.FS, name string, value any) error {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	d := NewDecoder(f).Includes(fsys, DefaultIncludeOptions)
+	d.file = name
+	return d.Decode(value)
+}
+
+// Includes allows the decoder to resolve include directives described
+// by opts. Files are read from fsys, relative paths are resolved relative
+// to the including file.
+func (d *Decoder) Includes(fsys fs.FS, opts IncludeOptions) *Decoder {
+	d.fsys = fsys
+	d.includeOpts = opts
+	return d
+}
+
+// directive returns the include directive at the current position, if any.
+func (d *Decoder) directive() string {
+	if d.fsys == nil {
+		return ""
+	}
+
+	// Check the longest directive first, as one can be a prefix of another.
+	directives := []string{d.includeOpts.DirDirective, d.includeOpts.Directive}
+	if len(directives[0]) < len(directives[1]) {
+		directives[0], directives[1] = directives[1], directives[0]
+	}
+
+	rest := d.buf[d.bufPos:]
+	for _, directive := range directives {
+		if directive == "" || !bytes.HasPrefix(rest, []byte(directive)) {
+			continue
+		}
+		if next := d.lookAhead(len(directive)); next == ' ' || next == '\t' {
+			return directive
+		}
+	}
+
+	return ""
+}
+
+func (d *Decoder) isIncludeKey(name string) bool {
+	return d.fsys != nil && d.includeOpts.Key != "" && name == d.includeOpts.Key
+}
+
+// include decodes the file or the files of the directory into
+// the document.
+func (d *Decoder) include(
+	doc *document,
+	current *docSection,
+	name string,
+	isDir bool,
+	line, column uint32,
+) error {
+	if name == "" {
+		return d.fileError(fmt.Errorf("expected a path to include at %d:%d", line, column))
+	}
+
+	name = path.Join(path.Dir(d.file), name)
+
+	if !isDir {
+		return d.includeFile(doc, current, name, line, column)
+	}
+
+	entries, err := fs.ReadDir(d.fsys, name)
+	if err != nil {
+		return d.fileError(fmt.Errorf("include failed at %d:%d: %w", line, column, err))
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if d.includeOpts.DirPattern != "" {
+			if ok, _ := path.Match(d.includeOpts.DirPattern, entry.Name()); !ok {
+				continue
+			}
+		}
+		err := d.includeFile(doc, current, path.Join(name, entry.Name()), line, column)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *Decoder) includeFile(
+	doc *document,
+	current *docSection,
+	name string,
+	line, column uint32,
+) error {
+	stack := append(slices.Clip(d.includeStack), d.file)
+
+	if slices.Contains(stack, name) {
+		return d.fileError(fmt.Errorf(
+			"include cycle with '%s' at %d:%d",
+			name,
+			line,
+			column,
+		))
+	}
+
+	maxDepth := d.includeOpts.MaxDepth
+	if maxDepth == 0 {
+		maxDepth = 10
+	}
+	if len(stack) > maxDepth {
+		return d.fileError(fmt.Errorf(
+			"include depth limit of %d exceeded at %d:%d",
+			maxDepth,
+			line,
+			column,
+		))
+	}
+
+	b, err := fs.ReadFile(d.fsys, name)
+	if err != nil {
+		return d.fileError(fmt.Errorf("include failed at %d:%d: %w", line, column, err))
+	}
+
+	child := Decoder{
+		file:         name,
+		fsys:         d.fsys,
+		includeOpts:  d.includeOpts,
+		includeStack: stack,
+	}
+	child.init(b)
+	return child.scanInto(doc, current)
+}
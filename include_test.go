@@ -0,0 +1,74 @@
+package ini_test
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/saffage/go-ini"
+)
+
+func TestDecodeFS(t *testing.T) {
+	type Server struct {
+		Host string `ini:"host"`
+		Port int    `ini:"port"`
+	}
+	type Config struct {
+		Server Server `ini:"server"`
+		Log    struct {
+			Level string `ini:"level"`
+		} `ini:"log"`
+	}
+
+	fsys := fstest.MapFS{
+		"etc/app.ini": {Data: []byte(
+			"[server]\nhost=localhost\ninclude=server/port.ini\n!includedir conf.d\n",
+		)},
+		"etc/server/port.ini":   {Data: []byte("port=8080\n")},
+		"etc/conf.d/10-log.ini": {Data: []byte("[log]\nlevel=info\n")},
+		"etc/conf.d/20-log.ini": {Data: []byte("[log]\nlevel=debug\n")},
+		"etc/conf.d/README":     {Data: []byte("not an INI file")},
+	}
+
+	var config Config
+	if err := ini.DecodeFS(fsys, "etc/app.ini", &config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Server != (Server{"localhost", 8080}) || config.Log.Level != "debug" {
+		t.Errorf("unexpected config: %+v", config)
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"a.ini":     {Data: []byte("[server]\n!include b.ini\n")},
+			"b.ini":     {Data: []byte("!include a.ini\n")},
+			"c.ini":     {Data: []byte("[server]\n!include bad/d.ini\n")},
+			"bad/d.ini": {Data: []byte("port=8080\n?\n")},
+			"e.ini":     {Data: []byte("[server]\n!include missing.ini\n")},
+			"x.ini":     {Data: []byte("!include y.ini\n")},
+			"y.ini":     {Data: []byte("!include z.ini\n")},
+			"z.ini":     {Data: []byte("[server]\n")},
+		}
+		tests := map[string]string{
+			"a.ini": "b.ini: include cycle with 'a.ini' at 1:1",
+			"c.ini": "bad/d.ini: unexpected character '?' at 2:1",
+			"e.ini": "e.ini: include failed at 2:1: open missing.ini: file does not exist",
+		}
+		for name, expect := range tests {
+			var config Config
+			err := ini.DecodeFS(fsys, name, &config)
+			if err == nil || err.Error() != expect {
+				t.Errorf("unexpected error for %s\nexpect: %s\ngot:    %v", name, expect, err)
+			}
+		}
+
+		var config Config
+		err := ini.NewDecoder(strings.NewReader("!include x.ini\n")).
+			Includes(fsys, ini.IncludeOptions{Directive: "!include", MaxDepth: 2}).
+			Decode(&config)
+		const expect = "y.ini: include depth limit of 2 exceeded at 1:1"
+		if err == nil || err.Error() != expect {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
@@ -1,11 +1,11 @@
 package ini
 
 import (
-	"encoding/hex"
 	"errors"
 	"fmt"
 	"reflect"
 	"slices"
+	"strings"
 )
 
 // Field represents a key-value pair in the INI tree.
@@ -15,19 +15,86 @@ type Field struct {
 	OmitEmpty bool
 	Commented bool
 
-	// TODO: add optional documentation for fields to emit it in the file.
+	// Doc is an optional documentation of the field that is written as
+	// a comment above the key while encoding.
+	Doc string
+
+	// Allowed is an optional list of values the field can have.
+	Allowed []string
+
+	// Default is an optional value in INI format that is decoded into
+	// the field when the key is missing in the file. Fields with
+	// OmitEmpty set are not encoded when they are equal to the default.
+	Default string
+
+	// Required reports whether the key must be present in the file.
+	Required bool
+
+	// Env is an optional name of the environment variable that overrides
+	// the value, see [Decoder.EnvOverrides].
+	Env string
+
+	// NoExpand disables expansion of environment variables and references
+	// in the value, see [Decoder.ExpandEnv] and [Decoder.Interpolate].
+	NoExpand bool
+
+	// Aliases are optional old names of the key that are decoded into
+	// the field, see [Decoder.Warnings]. Only Name is encoded.
+	Aliases []string
+
+	// Deprecated is an optional message reported when the key is present
+	// in the file, see [Decoder.Warnings].
+	Deprecated string
+
+	rules  rules // Constraints checked after decoding.
+	remain bool  // Whether the field is an entry of a ',remain' map.
+
+	// alloc sets nil pointers on the path to the value, it is called
+	// after the value is decoded.
+	alloc func()
 }
 
 func (f *Field) MarshalText() ([]byte, error) {
+	return f.marshalText(&EncoderOptions{})
+}
+
+func (f *Field) marshalText(opts *EncoderOptions) ([]byte, error) {
 	if f.Value.IsValid() {
-		if !f.OmitEmpty || !f.Value.IsZero() {
-			return encode(f.Value)
+		if !f.OmitEmpty || !f.isEmpty() {
+			return encode(f.Value, opts)
 		}
 		return nil, nil
 	}
 	return nil, errors.New("field have invalid value")
 }
 
+// isEmpty reports whether the field is behind a nil pointer, or has its
+// default value or, if it has no default value, the zero value. A zero
+// value is not empty if it differs from the default value, since it would
+// not be decoded back otherwise.
+func (f *Field) isEmpty() bool {
+	if f.alloc != nil {
+		// A nil pointer on the path to the value.
+		return true
+	}
+	if def, ok := f.defaultValue(); ok {
+		return reflect.DeepEqual(f.Value.Interface(), def.Interface())
+	}
+	return f.Value.IsZero()
+}
+
+// defaultValue decodes the default value of the field.
+func (f *Field) defaultValue() (reflect.Value, bool) {
+	if f.Default == "" || !f.Value.IsValid() || !f.Value.CanInterface() {
+		return reflect.Value{}, false
+	}
+	def := reflect.New(f.Value.Type()).Elem()
+	if decode(f.Default, def) != nil {
+		return reflect.Value{}, false
+	}
+	return def, true
+}
+
 func (f *Field) UnmarshalText(text []byte) error {
 	if f.Value.IsValid() {
 		return decode(string(text), f.Value)
@@ -40,6 +107,25 @@ type Section struct {
 	Name      string
 	Fields    []Field
 	OmitEmpty bool
+
+	// Doc is an optional documentation of the section that is written as
+	// a comment above the section header while encoding.
+	Doc string
+
+	// value is the Go value the section was built from.
+	value reflect.Value
+
+	// m is the map the section was built from. Map elements are not
+	// addressable, so decoded keys are stored in the map directly.
+	m reflect.Value
+
+	// null reports whether the section was built from a nil pointer or
+	// interface. Such sections are never encoded.
+	null bool
+
+	// alloc sets nil pointers on the path to the section, it is called
+	// after a key of the section is decoded.
+	alloc func()
 }
 
 // Field looks for a name in the section.
@@ -71,11 +157,13 @@ func (s *Section) UnmarshalINI(section Section) error {
 //   - struct{ S... }
 //   - map[string]S
 //   - [Marshaler]
+//   - []Section
 //
 // S must be one of:
 //   - struct{ F... }
 //   - map[string]F
 //   - [SectionMarshaler]
+//   - [RawSection]
 //
 // F must be one of:
 //   - int* \ uint*
@@ -84,6 +172,7 @@ func (s *Section) UnmarshalINI(section Section) error {
 //   - string
 //   - []F \ [N]F
 //   - [encoding.TextMarshaler]
+//   - [RawValue]
 //
 // # Struct tags
 //
@@ -92,20 +181,94 @@ func (s *Section) UnmarshalINI(section Section) error {
 //	`ini:"[key]{,flag}"`
 //
 // Unexported fields or fields with the key "-" are ignored. Every flag must
-// be prefixed with a comma.
+// be prefixed with a comma. Fields without a key are named after the Go
+// field, see [Encoder.Naming] and [Decoder.Naming]. Names of fields without
+// the 'ini' tag can be read from another tag, see [Encoder.FallbackTag] and
+// [Decoder.FallbackTag].
 //
 // The following flags are currently supported:
 //
 //   - inline – inline all fields\values into the current section.
 //     The type of this field must be S.
 //
-//   - omitempty – skip the field if it has a zero value.
+//   - omitempty – skip the field if it has a zero value. A section with this
+//     flag is skipped if all of its fields are omitted or have zero values.
+//     Pointers always have this flag, nil pointer and interface sections
+//     are never encoded.
 //
 //   - commented – prefix the field while encoding.
+//
+//   - doc=text – documentation of the field or the section that is written
+//     as a comment while encoding. The text cannot contain commas, use the
+//     separate tag for longer texts:
+//
+//     `comment:"text"`
+//
+//   - oneof=a b c – space-separated list of values the field can have.
+//
+//   - default=value – value that is decoded when the key is missing in
+//     the file. An omitempty field equal to its default is not encoded.
+//     Use the separate tag for values with commas:
+//
+//     `default:"a,b"`
+//
+//   - required – the key must be present in the file.
+//
+//   - min=x, max=x – bounds of a number, or of the length of a string
+//     or a list.
+//
+//   - len=n – exact length of a string or a list.
+//
+//   - regex=expr – regular expression a string must match. Use the separate
+//     tag for expressions with commas:
+//
+//     `regex:"^[a-z]{1,8}$"`
+//
+// The value of a field can be overridden by an environment variable, see
+// [Decoder.EnvOverrides]:
+//
+//	`env:"APP_DB_HOST"`
+//
+// Expansion of environment variables and references in the value can be
+// disabled with the 'noexpand' flag, see [Decoder.ExpandEnv].
+//
+// Keys that do not match any other field of a section are decoded into
+// a map[string]F field with the 'remain' flag, and sections that do not
+// match any other field of the value are decoded into a root
+// map[string]map[string]F field with this flag. Entries of such maps are
+// encoded as any other keys and sections:
+//
+//	type Server struct {
+//		Host  string            `ini:"host"`
+//		Extra map[string]string `ini:",remain"`
+//	}
+//
+// The oneof, required, min, max, len and regex constraints are checked
+// after decoding, see [ValidationError].
+//
+// Old names of a renamed key and a deprecation message can be set with
+// separate tags, see [Decoder.Warnings]:
+//
+//	`alias:"old_name,older_name" deprecated:"use 'new_name' instead"`
 func SectionsOf(value any) ([]Section, error) {
+	return (&treeOptions{}).sectionsOf(value)
+}
+
+// treeOptions controls how an INI tree is built from a Go value.
+type treeOptions struct {
+	keyOrder func(a, b string) int
+	naming   func(name string) string
+	tagKey   string // Tag read if a field has no 'ini' tag.
+}
+
+func (opts *treeOptions) sectionsOf(value any) ([]Section, error) {
 	v := reflect.Indirect(reflect.ValueOf(value))
 	t := v.Type()
 
+	if t == tSections {
+		return v.Interface().([]Section), nil
+	}
+
 	if t.Implements(tMarshaler) {
 		return v.Interface().(Marshaler).MarshalINI()
 	}
@@ -118,11 +281,11 @@ func SectionsOf(value any) ([]Section, error) {
 		if t.Key().Kind() != reflect.String {
 			return nil, fmt.Errorf("cannot use type %s as map key", t.String())
 		}
-		return sectionsOfMap(v)
+		return opts.sectionsOfMap(v)
 	}
 
 	if t.Kind() == reflect.Struct {
-		return sectionsOfStruct(v)
+		return opts.sectionsOfStruct(v)
 	}
 
 	return nil, fmt.Errorf(
@@ -131,61 +294,142 @@ func SectionsOf(value any) ([]Section, error) {
 	)
 }
 
-func sectionsOfMap(root reflect.Value) ([]Section, error) {
-	return walkMap(root, func(v reflect.Value, flags flags) (Section, error) {
-		flags.inline = true
-		fields, err := fieldsOf(v, nil, reflect.StructField{}, flags)
+func (opts *treeOptions) sectionsOfMap(root reflect.Value) ([]Section, error) {
+	return walkMap(opts, root, func(v reflect.Value, flags flags) (Section, error) {
+		section, err := opts.sectionOf(v, nil, reflect.StructField{}, flags)
 		if err != nil {
-			return Section{}, err
+			return section, err
 		}
-		return Section{
-			Name:      flags.key,
-			Fields:    fields,
-			OmitEmpty: flags.omitempty,
-		}, nil
+		return variantSection(section, root.Type().Elem(), v)
 	})
 }
 
-func sectionsOfStruct(root reflect.Value) ([]Section, error) {
-	return walkStructFields(
+func (opts *treeOptions) sectionsOfStruct(root reflect.Value) ([]Section, error) {
+	sections, err := walkStructFields(
+		opts,
 		root,
-		func(v reflect.Value, f reflect.StructField, flags flags) (Section, error) {
-			flags.inline = true
-			fields, err := fieldsOf(v, root.Type(), f, flags)
+		nil,
+		func(v reflect.Value, f reflect.StructField, flags flags) ([]Section, error) {
+			if flags.remain {
+				return opts.remainSections(v, root.Type(), f)
+			}
+			section, err := opts.sectionOf(v, root.Type(), f, flags)
 			if err != nil {
-				return Section{}, err
+				return nil, err
 			}
-			return Section{
-				Name:      flags.key,
-				Fields:    fields,
-				OmitEmpty: flags.omitempty,
-			}, nil
+			section, err = variantSection(section, f.Type, v)
+			return []Section{section}, err
 		},
 	)
+	return slices.Concat(sections...), err
 }
 
-func fieldsOf(
+// remainSections builds sections from a ',remain' map of the root value,
+// which keeps sections that do not match any other field.
+func (opts *treeOptions) remainSections(
+	v reflect.Value,
+	structType reflect.Type,
+	field reflect.StructField,
+) ([]Section, error) {
+	t := field.Type
+	if t.Kind() != reflect.Map || t.Key().Kind() != reflect.String ||
+		t.Elem().Kind() != reflect.Map || t.Elem().Key().Kind() != reflect.String ||
+		!isBasicType(t.Elem().Elem()) {
+		return nil, errRemainType(field.Name, structType.String(), "map[string]map[string]T")
+	}
+	return opts.sectionsOfMap(v)
+}
+
+func (opts *treeOptions) sectionOf(
 	v reflect.Value,
 	structType reflect.Type,
 	field reflect.StructField,
 	flags flags,
-) ([]Field, error) {
+) (Section, error) {
+	if !v.IsValid() {
+		return Section{
+			Name:      flags.key,
+			OmitEmpty: true,
+			Doc:       flags.doc,
+			null:      true,
+		}, nil
+	}
+	if v.Type() == tRawSection {
+		return rawSectionOf(v, flags), nil
+	}
+	if section, ok, err := marshalSection(v); ok {
+		section.Name = flags.key
+		section.OmitEmpty = section.OmitEmpty || flags.omitempty
+		section.value = v
+		if flags.doc != "" {
+			section.Doc = flags.doc
+		}
+		return section, err
+	}
+	flags.inline = true
+	fields, err := opts.fieldsOf(v, structType, field, flags)
+	if err != nil {
+		return Section{}, err
+	}
+	section := Section{
+		Name:      flags.key,
+		Fields:    fields,
+		OmitEmpty: flags.omitempty,
+		Doc:       flags.doc,
+		null:      flags.alloc != nil,
+		alloc:     flags.alloc,
+		value:     v,
+	}
+	if v.Kind() == reflect.Map {
+		section.m = v
+	}
+	return section, nil
+}
+
+// marshalSection builds a section using the [SectionMarshaler]
+// implementation of the value, if any.
+func marshalSection(v reflect.Value) (Section, bool, error) {
 	t := v.Type()
 
 	if t.Implements(tSectionMarshaler) {
 		section, err := v.Interface().(SectionMarshaler).MarshalINI()
-		if err != nil {
-			return nil, err
-		}
-		return section.Fields, nil
+		return section, true, err
 	}
 
 	if v.CanAddr() && reflect.PointerTo(t).Implements(tSectionMarshaler) {
 		section, err := v.Addr().Interface().(SectionMarshaler).MarshalINI()
-		if err != nil {
-			return nil, err
+		return section, true, err
+	}
+
+	return Section{}, false, nil
+}
+
+func (opts *treeOptions) fieldsOf(
+	v reflect.Value,
+	structType reflect.Type,
+	field reflect.StructField,
+	flags flags,
+) ([]Field, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	t := v.Type()
+
+	if section, ok, err := marshalSection(v); ok {
+		return section.Fields, err
+	}
+
+	if flags.remain {
+		if t.Kind() != reflect.Map || t.Key().Kind() != reflect.String ||
+			!isBasicType(t.Elem()) {
+			return nil, errRemainType(field.Name, structType.String(), "map[string]T")
+		}
+		fields, err := opts.fieldsOfMap(v)
+		for i := range fields {
+			fields[i].remain = true
 		}
-		return section.Fields, nil
+		return fields, err
 	}
 
 	if t.Kind() == reflect.Map && flags.inline {
@@ -195,20 +439,30 @@ func fieldsOf(
 		if t.Key().Kind() != reflect.String {
 			return nil, fmt.Errorf("map key type must be string")
 		}
-		return fieldsOfMap(v)
+		return opts.fieldsOfMap(v)
 	}
 
 	if t.Kind() == reflect.Struct && flags.inline {
-		return fieldsOfStruct(v)
+		return opts.fieldsOfStruct(v, flags.alloc)
 	}
 
 	if isBasicType(t) {
 		return []Field{
 			{
-				Name:      flags.key,
-				Value:     v,
-				OmitEmpty: flags.omitempty,
-				Commented: flags.commented,
+				Name:       flags.key,
+				Value:      v,
+				OmitEmpty:  flags.omitempty,
+				Commented:  flags.commented,
+				Doc:        flags.doc,
+				Allowed:    flags.oneof,
+				Default:    flags.def,
+				Required:   flags.required,
+				Env:        flags.env,
+				NoExpand:   flags.noexpand,
+				Aliases:    flags.aliases,
+				Deprecated: flags.deprecated,
+				rules:      flags.rules,
+				alloc:      flags.alloc,
 			},
 		}, nil
 	}
@@ -228,8 +482,8 @@ func fieldsOf(
 	)
 }
 
-func fieldsOfMap(section reflect.Value) ([]Field, error) {
-	return walkMap(section, func(v reflect.Value, flags flags) (Field, error) {
+func (opts *treeOptions) fieldsOfMap(section reflect.Value) ([]Field, error) {
+	return walkMap(opts, section, func(v reflect.Value, flags flags) (Field, error) {
 		return Field{
 			Name:      flags.key,
 			Value:     v,
@@ -239,11 +493,16 @@ func fieldsOfMap(section reflect.Value) ([]Field, error) {
 	})
 }
 
-func fieldsOfStruct(section reflect.Value) ([]Field, error) {
+func (opts *treeOptions) fieldsOfStruct(
+	section reflect.Value,
+	alloc func(),
+) ([]Field, error) {
 	fields, err := walkStructFields(
+		opts,
 		section,
+		alloc,
 		func(v reflect.Value, f reflect.StructField, flags flags) ([]Field, error) {
-			fields, err := fieldsOf(v, section.Type(), f, flags)
+			fields, err := opts.fieldsOf(v, section.Type(), f, flags)
 			if err != nil {
 				return nil, err
 			}
@@ -253,7 +512,52 @@ func fieldsOfStruct(section reflect.Value) ([]Field, error) {
 	return slices.Concat(fields...), err
 }
 
+// remainOf returns the ',remain' map of the struct, if any. Maps of
+// inlined structs are looked up as well.
+func remainOf(v reflect.Value) reflect.Value {
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}
+	}
+
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		flags, err := parseTag(v.Type(), field)
+		if err != nil || flags.key == "-" {
+			continue
+		}
+
+		switch {
+		case flags.remain:
+			return v.Field(i)
+
+		case flags.inline:
+			if remain := remainOf(reflect.Indirect(v.Field(i))); remain.IsValid() {
+				return remain
+			}
+		}
+	}
+
+	return reflect.Value{}
+}
+
+func errRemainType(field, t, expected string) error {
+	return fmt.Errorf(
+		"type of field '%s' in type '%s' with 'remain' flag must be %s",
+		field,
+		t,
+		expected,
+	)
+}
+
 func isBasicType(t reflect.Type) bool {
+	if t == tRawValue {
+		return true
+	}
+
 	switch t.Kind() {
 	case reflect.Bool, reflect.Array, reflect.Slice, reflect.String,
 		reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
@@ -266,42 +570,27 @@ func isBasicType(t reflect.Type) bool {
 }
 
 func isZeroOrEmpty(v reflect.Value) bool {
+	if v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
 	return v.IsZero() ||
 		(v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.Len() == 0
 }
 
-func quoteString(s string) string {
-	buf := make([]byte, 0, 2+len(s)+len(s)/2)
-	buf = append(buf, '\'')
-	for _, r := range s {
-		switch {
-		case r >= 0x20 && r <= 0x7E:
-			buf = append(buf, byte(r))
-
-		case r == '\n':
-			buf = append(buf, "\\n"...)
-
-		case r == '\r':
-			buf = append(buf, "\\r"...)
-
-		default:
-			encoded := hex.EncodeToString([]byte(string(r)))
-			for i := 0; i < len(encoded); i += 2 {
-				buf = append(buf, "\\x"...)
-				buf = append(buf, encoded[i])
-				buf = append(buf, encoded[i+1])
-			}
-		}
-	}
-	buf = append(buf, '\'')
-	return string(buf)
-}
-
 type walkStructFunc[T any] func(v reflect.Value, f reflect.StructField, flags flags) (T, error)
 
 type walkMapFunc[T any] func(v reflect.Value, flags flags) (T, error)
 
-func walkStructFields[T any](v reflect.Value, f walkStructFunc[T]) ([]T, error) {
+// walkStructFields calls f for every exported field of the struct.
+//
+// Nil pointers are replaced with new values if they can be set, alloc is
+// called to set the pointers (see [Field.alloc]).
+func walkStructFields[T any](
+	opts *treeOptions,
+	v reflect.Value,
+	alloc func(),
+	f walkStructFunc[T],
+) ([]T, error) {
 	vals := make([]T, 0, v.NumField())
 	errs := make([]error, 0)
 
@@ -319,23 +608,39 @@ func walkStructFields[T any](v reflect.Value, f walkStructFunc[T]) ([]T, error)
 			continue
 		}
 
-		if flags.key == "-" {
+		if flags.key == "-" || opts.fallbackTag(field, &flags) {
 			continue
 		}
 
 		if flags.key == "" {
-			flags.key = field.Name
+			flags.key = opts.fieldName(field.Name)
 		}
 
 		fieldValue := v.Field(i)
+		flags.alloc = alloc
 
 		switch fieldValue.Kind() {
 		case reflect.Pointer:
 			flags.omitempty = true
+			if fieldValue.IsNil() && fieldValue.CanSet() {
+				ptr, value := fieldValue, reflect.New(fieldValue.Type().Elem())
+				flags.alloc = func() {
+					if alloc != nil {
+						alloc()
+					}
+					if ptr.IsNil() {
+						ptr.Set(value)
+					}
+				}
+				fieldValue = value
+			}
 			fallthrough
 
 		case reflect.Interface:
 			fieldValue = fieldValue.Elem()
+			if fieldValue.Kind() == reflect.Pointer {
+				fieldValue = fieldValue.Elem()
+			}
 		}
 
 		val, err := f(fieldValue, field, flags)
@@ -350,13 +655,18 @@ func walkStructFields[T any](v reflect.Value, f walkStructFunc[T]) ([]T, error)
 	return vals, errors.Join(errs...)
 }
 
-func walkMap[T any](v reflect.Value, f walkMapFunc[T]) ([]T, error) {
+func walkMap[T any](opts *treeOptions, v reflect.Value, f walkMapFunc[T]) ([]T, error) {
 	vals := make([]T, 0, v.Len())
 	errs := make([]error, 0)
 
-	for iter := v.MapRange(); iter.Next(); {
+	keys := v.MapKeys()
+	slices.SortFunc(keys, func(a, b reflect.Value) int {
+		return opts.compareKeys(a.String(), b.String())
+	})
+
+	for _, key := range keys {
 		omitempty := false
-		k, v := iter.Key().Interface().(string), iter.Value()
+		k, v := key.String(), v.MapIndex(key)
 
 		switch v.Kind() {
 		case reflect.Pointer:
@@ -365,6 +675,9 @@ func walkMap[T any](v reflect.Value, f walkMapFunc[T]) ([]T, error) {
 
 		case reflect.Interface:
 			v = v.Elem()
+			if v.Kind() == reflect.Pointer {
+				v = v.Elem()
+			}
 		}
 
 		val, err := f(v, flags{key: k, omitempty: omitempty})
@@ -378,3 +691,48 @@ func walkMap[T any](v reflect.Value, f walkMapFunc[T]) ([]T, error) {
 
 	return vals, errors.Join(errs...)
 }
+
+// fallbackTag reads the name and the omitempty option of the field from
+// the fallback tag, if the field has no 'ini' tag. It reports whether
+// the fallback tag ignores the field, that is, the tag is "-" without
+// a comma, while "-," names the key "-".
+func (opts *treeOptions) fallbackTag(field reflect.StructField, flags *flags) bool {
+	if opts.tagKey == "" {
+		return false
+	}
+	if _, ok := field.Tag.Lookup("ini"); ok {
+		return false
+	}
+	tag, ok := field.Tag.Lookup(opts.tagKey)
+	if !ok {
+		return false
+	}
+
+	name, options, found := strings.Cut(tag, ",")
+	if !found && strings.TrimSpace(name) == "-" {
+		return true
+	}
+	flags.key = strings.TrimSpace(name)
+	for _, option := range strings.Split(options, ",") {
+		if strings.TrimSpace(option) == "omitempty" {
+			flags.omitempty = true
+		}
+	}
+	return false
+}
+
+// fieldName returns the name of a section or a key of the struct field
+// without a name in its tag.
+func (opts *treeOptions) fieldName(name string) string {
+	if opts.naming != nil {
+		return opts.naming(name)
+	}
+	return name
+}
+
+func (opts *treeOptions) compareKeys(a, b string) int {
+	if opts.keyOrder != nil {
+		return opts.keyOrder(a, b)
+	}
+	return strings.Compare(a, b)
+}
@@ -17,3 +17,32 @@ func TestSectionsOf(t *testing.T) {
 		}
 	})
 }
+
+func TestSectionsOfTags(t *testing.T) {
+	t.Run("doc", func(t *testing.T) {
+		var file struct {
+			A struct {
+				X int `ini:"x,doc=Some value."`
+			} `comment:"Section A."`
+		}
+		sections, err := ini.SectionsOf(&file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sections[0].Doc != "Section A." || sections[0].Fields[0].Doc != "Some value." {
+			t.Errorf("unexpected tree: %+v", sections)
+		}
+	})
+	t.Run("invalid flags", func(t *testing.T) {
+		var file struct {
+			A struct {
+				X int `ini:"x,doc"`
+				Y int `ini:"y,omitempty=true"`
+				Z int `ini:"z,unknown=1"`
+			}
+		}
+		if _, err := ini.SectionsOf(&file); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
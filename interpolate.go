@@ -0,0 +1,249 @@
+package ini
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Interpolate allows the decoder to replace references to other keys in
+// values with values of these keys:
+//
+//	[paths]
+//	base=/opt/app
+//	data=${base}/data
+//	[server]
+//	root=${paths.data}/www
+//
+// A reference without a section name refers to a key of the same section.
+// Use '$$' to write a single '$'. References to undefined keys and
+// reference cycles are reported as errors. Values of fields with
+// the 'noexpand' flag are decoded as they are written.
+func (d *Decoder) Interpolate(flag bool) *Decoder {
+	d.interpolate = flag
+	return d
+}
+
+// InterpolatePercent allows the decoder to replace configparser-style
+// references '%(key)s' to keys of the same section. Use '%%' to write
+// a single '%'.
+func (d *Decoder) InterpolatePercent(flag bool) *Decoder {
+	d.interpolatePercent = flag
+	return d
+}
+
+// interpolator resolves references in values of a document.
+type interpolator struct {
+	doc      *document
+	dollar   bool
+	percent  bool
+	env      bool
+	getenv   func(string) (string, bool)
+	noexpand map[string]map[string]bool
+	resolved map[*docSection]map[string]string
+	visiting map[*docSection]map[string]bool
+}
+
+func (d *Decoder) interpolateDocument(doc *document, sections []Section) error {
+	interp := d.interpolator(doc, sections)
+	for _, section := range doc.sections {
+		for i := range section.keys {
+			text, err := interp.resolve(section, section.keys[i])
+			if err != nil {
+				return err
+			}
+			section.keys[i].value.text = text
+		}
+	}
+	return nil
+}
+
+// interpolator returns an interpolator of the document. Fields of
+// the sections with the 'noexpand' flag are not expanded.
+func (d *Decoder) interpolator(doc *document, sections []Section) *interpolator {
+	interp := &interpolator{
+		doc:      doc,
+		dollar:   d.interpolate,
+		percent:  d.interpolatePercent,
+		env:      d.expandEnv,
+		getenv:   d.getenv,
+		noexpand: map[string]map[string]bool{},
+		resolved: map[*docSection]map[string]string{},
+		visiting: map[*docSection]map[string]bool{},
+	}
+
+	for _, section := range sections {
+		for _, field := range section.Fields {
+			if field.NoExpand {
+				if interp.noexpand[section.Name] == nil {
+					interp.noexpand[section.Name] = map[string]bool{}
+				}
+				interp.noexpand[section.Name][field.Name] = true
+			}
+		}
+	}
+
+	return interp
+}
+
+func (interp *interpolator) resolve(section *docSection, key docKey) (string, error) {
+	if text, ok := interp.resolved[section][key.name]; ok {
+		return text, nil
+	}
+
+	if interp.visiting[section][key.name] {
+		return "", fmt.Errorf(
+			"reference cycle in key '%s' at %s",
+			key.name,
+			key.position(),
+		)
+	}
+
+	if interp.visiting[section] == nil {
+		interp.visiting[section] = map[string]bool{}
+		interp.resolved[section] = map[string]string{}
+	}
+
+	if interp.noexpand[section.name][key.name] {
+		interp.resolved[section][key.name] = key.value.text
+		return key.value.text, nil
+	}
+
+	interp.visiting[section][key.name] = true
+	text, err := interp.expand(section, key, key.value.text, interp.env)
+	interp.visiting[section][key.name] = false
+
+	if err != nil {
+		return "", err
+	}
+
+	interp.resolved[section][key.name] = text
+	return text, nil
+}
+
+// expand replaces references in the text of the key. If env is true,
+// environment variables are expanded as well.
+func (interp *interpolator) expand(
+	section *docSection,
+	key docKey,
+	s string,
+	env bool,
+) (string, error) {
+	dollar := interp.dollar || env
+	buf := strings.Builder{}
+
+	for i := 0; i < len(s); i++ {
+		switch {
+		case dollar && strings.HasPrefix(s[i:], "$$"),
+			interp.percent && strings.HasPrefix(s[i:], "%%"):
+			buf.WriteByte(s[i])
+			i++
+
+		case dollar && strings.HasPrefix(s[i:], "${"):
+			end := matchingBrace(s[i:])
+			if end < 0 {
+				return "", errReference("unterminated reference", s[i:], key)
+			}
+			text, err := interp.braced(section, key, s[i+2:i+end], env)
+			if err != nil {
+				return "", err
+			}
+			buf.WriteString(text)
+			i += end
+
+		case env && s[i] == '$' && envNameLen(s[i+1:]) > 0:
+			n := envNameLen(s[i+1:])
+			value, _ := interp.getenv(s[i+1 : i+1+n])
+			buf.WriteString(value)
+			i += n
+
+		case interp.percent && strings.HasPrefix(s[i:], "%("):
+			end := strings.Index(s[i:], ")s")
+			if end < 0 {
+				return "", errReference("unterminated reference", s[i:], key)
+			}
+			name := s[i+2 : i+end]
+			text, err := interp.reference(section, key, name, false)
+			if err != nil {
+				return "", err
+			}
+			buf.WriteString(text)
+			i += end + 1
+
+		default:
+			buf.WriteByte(s[i])
+		}
+	}
+
+	return buf.String(), nil
+}
+
+// braced resolves the contents of a '${...}' reference, which is either
+// a reference to a key or, if env is true, an environment variable.
+func (interp *interpolator) braced(
+	section *docSection,
+	key docKey,
+	ref string,
+	env bool,
+) (string, error) {
+	if interp.dollar {
+		if target, targetKey, ok := interp.lookup(section, ref, true); ok {
+			return interp.resolve(target, targetKey)
+		}
+	}
+
+	if env {
+		if n := envNameLen(ref); n > 0 {
+			return interp.expandEnvRef(section, key, ref[:n], ref[n:])
+		}
+	}
+
+	return "", errReference("undefined reference", ref, key)
+}
+
+// reference resolves the name of a key referenced from the key provided.
+// If qualified is true, the name may be prefixed with a section name.
+func (interp *interpolator) reference(
+	section *docSection,
+	key docKey,
+	name string,
+	qualified bool,
+) (string, error) {
+	target, targetKey, ok := interp.lookup(section, name, qualified)
+	if ok {
+		return interp.resolve(target, targetKey)
+	}
+	return "", errReference("undefined reference", name, key)
+}
+
+func (interp *interpolator) lookup(
+	section *docSection,
+	name string,
+	qualified bool,
+) (*docSection, docKey, bool) {
+	if qualified {
+		for i := strings.LastIndexByte(name, '.'); i > 0; {
+			if s := interp.doc.section(name[:i]); s != nil {
+				if key, ok := s.key(name[i+1:]); ok {
+					return s, key, true
+				}
+			}
+			i = strings.LastIndexByte(name[:i], '.')
+		}
+	}
+
+	if key, ok := section.key(name); ok {
+		return section, key, true
+	}
+
+	return nil, docKey{}, false
+}
+
+func errReference(msg, name string, key docKey) error {
+	return fmt.Errorf(
+		"%s '%s' in key '%s' at %s",
+		msg,
+		name,
+		key.name,
+		key.position(),
+	)
+}
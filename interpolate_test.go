@@ -0,0 +1,65 @@
+package ini_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/saffage/go-ini"
+)
+
+func TestInterpolate(t *testing.T) {
+	const data = `[paths]
+base=/opt/app
+data=${base}/data
+price=$$5
+[server]
+root=${paths.data}/www
+name=%(host)s:8080
+host=example.com
+`
+	t.Run("enabled", func(t *testing.T) {
+		m := map[string]map[string]string{}
+		d := ini.NewDecoder(strings.NewReader(data)).
+			Interpolate(true).
+			InterpolatePercent(true)
+		if err := d.Decode(&m); err != nil {
+			t.Fatal(err)
+		}
+		expect := map[string]string{
+			"paths.data":  "/opt/app/data",
+			"paths.price": "$5",
+			"server.root": "/opt/app/data/www",
+			"server.name": "example.com:8080",
+		}
+		for ref, value := range expect {
+			section, key, _ := strings.Cut(ref, ".")
+			if m[section][key] != value {
+				t.Errorf("unexpected value of %s: %q", ref, m[section][key])
+			}
+		}
+	})
+	t.Run("disabled", func(t *testing.T) {
+		m, err := ini.UnmarshalMap([]byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m["paths"]["data"] != "${base}/data" {
+			t.Errorf("unexpected value: %q", m["paths"]["data"])
+		}
+	})
+	t.Run("errors", func(t *testing.T) {
+		tests := map[string]string{
+			"[a]\nx=${y}\n":       "undefined reference 'y' in key 'x' at 2:1",
+			"[a]\nx=${y}\ny=${x}": "reference cycle in key 'x' at 2:1",
+			"[a]\nx=${y\n":        "unterminated reference '${y' in key 'x' at 2:1",
+		}
+		for data, expect := range tests {
+			m := map[string]any{}
+			err := ini.NewDecoder(bytes.NewReader([]byte(data))).Interpolate(true).Decode(&m)
+			if err == nil || err.Error() != expect {
+				t.Errorf("unexpected error for %q\nexpect: %s\ngot:    %v", data, expect, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,295 @@
+package ini
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// KeyValue is a key set by a [Source].
+type KeyValue struct {
+	Section string
+
+	// Parent is the parent section or the profile of the section, as
+	// written in the header '[section : parent]', see [Unmarshal].
+	// It is usually empty.
+	Parent string
+
+	Key    string // Empty if the entry only declares the section.
+	Value  string // Used as is, without unquoting, unless Raw is set.
+	Raw    bool   // Whether Value is written as in an INI file.
+	Origin string // Name of the file or another description of the source.
+	Line   int    // Zero if the position of the key is unknown.
+	Column int    // Zero if the position of the key is unknown.
+}
+
+// Source is a layer of configuration read by a [Loader].
+type Source interface {
+	// Load returns the keys set by the source. Sections are built from
+	// the value being loaded (see [SectionsOf]) and are nil if the value
+	// has no schema.
+	Load(sections []Section) ([]KeyValue, error)
+}
+
+// Loader fills a Go value from several sources.
+//
+// Sources are applied in the order they are passed to [NewLoader],
+// a key set by a later source replaces the same key set by an earlier
+// one. Keys of every source are merged before keys of the "DEFAULT"
+// section and parent sections are inherited, so a later source can
+// override inherited keys as well. A typical order is:
+//
+//	ini.NewLoader(
+//		ini.Defaults(),
+//		ini.OptionalFile(root, "etc/app.conf"),
+//		ini.OptionalFile(home, ".config/app.conf"),
+//		ini.OptionalFile(cwd, "app.conf"),
+//		ini.Env("APP", nil),
+//		ini.Overrides(flags...),
+//	)
+type Loader struct {
+	sources []Source
+	decoder *Decoder
+}
+
+// NewLoader returns a loader that applies the sources in the order
+// they are passed.
+func NewLoader(sources ...Source) *Loader {
+	return &Loader{sources: sources}
+}
+
+// Add appends the sources, which take precedence over the sources
+// added earlier.
+func (l *Loader) Add(sources ...Source) *Loader {
+	l.sources = append(l.sources, sources...)
+	return l
+}
+
+// Decoder sets the decoder whose settings, such as [Decoder.Profile] or
+// [Decoder.Interpolate], are used to decode the keys. Its input is not read.
+func (l *Loader) Decoder(d *Decoder) *Loader {
+	l.decoder = d
+	return l
+}
+
+// Load fills the value with the keys of every source. The returned
+// origins report which source set each key last.
+//
+// More information can be found in the [Unmarshal] function documentation.
+func (l *Loader) Load(value any) (Origins, error) {
+	d := &Decoder{}
+	if l.decoder != nil {
+		d = l.decoder
+	}
+
+	var sections []Section
+	if v := reflect.ValueOf(value); !isUntypedTarget(v) {
+		var err error
+		if sections, err = d.tree.sectionsOf(value); err != nil {
+			return nil, err
+		}
+	}
+
+	doc := &document{}
+	for _, source := range l.sources {
+		keys, err := source.Load(sections)
+		if err != nil {
+			return nil, err
+		}
+		for _, kv := range keys {
+			if err := doc.addKeyValue(kv); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := doc.inherit(d.inheritOptions()); err != nil {
+		return nil, err
+	}
+
+	origins := Origins{}
+	if err := d.decodeDocument(doc, value, nil, origins); err != nil {
+		return origins, err
+	}
+	return origins, nil
+}
+
+// addKeyValue adds the key to the section of its header.
+func (doc *document) addKeyValue(kv KeyValue) error {
+	line, column := uint32(kv.Line), uint32(kv.Column)
+	s := doc.addHeader(kv.Section, kv.Parent, kv.Origin, line, column)
+	if kv.Key == "" {
+		return nil
+	}
+
+	key := docKey{
+		name:   kv.Key,
+		value:  docValue{text: kv.Value},
+		file:   kv.Origin,
+		line:   line,
+		column: column,
+	}
+	if kv.Raw {
+		raw := RawValue{Text: kv.Value, File: kv.Origin, Line: kv.Line, Column: kv.Column}
+		var err error
+		if key, err = raw.key(kv.Key); err != nil {
+			return err
+		}
+	}
+
+	s.set(key)
+	return nil
+}
+
+// Defaults returns a source of the default values of fields, see
+// the 'default' flag in the [SectionsOf] documentation. Its origin
+// is "defaults".
+//
+// Default values are decoded even without this source, adding it only
+// reports them in the origins and lets other sources override them.
+func Defaults() Source {
+	return defaultsSource{}
+}
+
+type defaultsSource struct{}
+
+func (defaultsSource) Load(sections []Section) ([]KeyValue, error) {
+	var keys []KeyValue
+	for _, section := range sections {
+		if section.m.IsValid() {
+			continue
+		}
+		for _, field := range section.Fields {
+			if field.Default != "" {
+				keys = append(keys, KeyValue{
+					Section: section.Name,
+					Key:     field.Name,
+					Value:   field.Default,
+					Origin:  "defaults",
+				})
+			}
+		}
+	}
+	return keys, nil
+}
+
+// File returns a source of the INI file named name in fsys. Include
+// directives of the file, described by [DefaultIncludeOptions], are
+// resolved relative to the including file.
+//
+// Use [os.DirFS] to read files of the operating system.
+func File(fsys fs.FS, name string) Source {
+	return fileSource{fsys: fsys, name: name}
+}
+
+// OptionalFile is like [File], but the source is empty if the file
+// does not exist.
+func OptionalFile(fsys fs.FS, name string) Source {
+	return fileSource{fsys: fsys, name: name, optional: true}
+}
+
+type fileSource struct {
+	fsys     fs.FS
+	name     string
+	optional bool
+}
+
+func (s fileSource) Load([]Section) ([]KeyValue, error) {
+	b, err := fs.ReadFile(s.fsys, s.name)
+	if err != nil {
+		if s.optional && errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	d := &Decoder{file: s.name}
+	d.Includes(s.fsys, DefaultIncludeOptions)
+	d.init(b)
+
+	doc := &document{}
+	if err := d.scanInto(doc, nil); err != nil {
+		return nil, err
+	}
+
+	// Sections are returned as they are written, they are merged by
+	// the loader.
+	var keys []KeyValue
+	for _, section := range doc.sections {
+		keys = append(keys, KeyValue{
+			Section: section.name,
+			Parent:  section.parent,
+			Origin:  section.file,
+			Line:    int(section.line),
+			Column:  int(section.column),
+		})
+		for _, key := range section.keys {
+			keys = append(keys, KeyValue{
+				Section: section.name,
+				Parent:  section.parent,
+				Key:     key.name,
+				Value:   key.value.raw,
+				Raw:     true,
+				Origin:  key.file,
+				Line:    int(key.line),
+				Column:  int(key.column),
+			})
+		}
+	}
+	return keys, nil
+}
+
+// Env returns a source of environment variables named as described in
+// the [Decoder.EnvOverrides] documentation. Lookup is used to look up
+// the variables, [os.LookupEnv] is used if it is nil. The origin of
+// a key is the name of the variable prefixed with '$'.
+func Env(prefix string, lookup func(key string) (string, bool)) Source {
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+	return envSource{prefix: prefix, lookup: lookup}
+}
+
+type envSource struct {
+	prefix string
+	lookup func(string) (string, bool)
+}
+
+func (s envSource) Load(sections []Section) ([]KeyValue, error) {
+	return envKeys(sections, s.prefix, s.lookup), nil
+}
+
+// Overrides returns a source of overrides written as 'section.key=value',
+// for example values of a '-c' command line flag. Section names may
+// contain dots, the key name follows the last one. Values are used as is.
+// The origin of a key is "command line".
+func Overrides(overrides ...string) Source {
+	return overridesSource(overrides)
+}
+
+type overridesSource []string
+
+func (s overridesSource) Load([]Section) ([]KeyValue, error) {
+	keys := make([]KeyValue, 0, len(s))
+	for _, override := range s {
+		name, value, found := strings.Cut(override, "=")
+		name = strings.TrimSpace(name)
+		i := strings.LastIndexByte(name, '.')
+		if !found || i <= 0 || i == len(name)-1 {
+			return nil, fmt.Errorf(
+				"invalid override '%s': expected 'section.key=value'",
+				override,
+			)
+		}
+		keys = append(keys, KeyValue{
+			Section: name[:i],
+			Key:     name[i+1:],
+			Value:   value,
+			Origin:  "command line",
+		})
+	}
+	return keys, nil
+}
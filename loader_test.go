@@ -0,0 +1,224 @@
+package ini_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/saffage/go-ini"
+)
+
+// mapSource is a source of keys defined in tests.
+type mapSource map[string]map[string]string
+
+func (s mapSource) Load([]ini.Section) ([]ini.KeyValue, error) {
+	var keys []ini.KeyValue
+	for section, m := range s {
+		for key, value := range m {
+			keys = append(keys, ini.KeyValue{
+				Section: section,
+				Key:     key,
+				Value:   value,
+				Origin:  "test",
+			})
+		}
+	}
+	return keys, nil
+}
+
+// sourceFunc is a source that calls the function.
+type sourceFunc func(sections []ini.Section) ([]ini.KeyValue, error)
+
+func (f sourceFunc) Load(sections []ini.Section) ([]ini.KeyValue, error) {
+	return f(sections)
+}
+
+func TestLoader(t *testing.T) {
+	type Config struct {
+		Server struct {
+			Host    string   `ini:"host,default=localhost"`
+			Port    int      `ini:"port,default=80"`
+			Aliases []string `ini:"aliases"`
+		} `ini:"server"`
+		Log struct {
+			Level string `ini:"level,default=info"`
+			File  string `ini:"file" env:"LOG_FILE"`
+		} `ini:"log"`
+	}
+
+	fsys := fstest.MapFS{
+		"etc/app.conf":       {Data: []byte("[server]\nhost=example.com\nport=8000\n")},
+		"home/.app.conf":     {Data: []byte("[server]\naliases='a', 'b'\n!include log.conf\n")},
+		"home/log.conf":      {Data: []byte("[log]\nlevel=warn\n")},
+		"home/.app.conf.bad": {Data: []byte("[server]\n?\n")},
+	}
+	env := map[string]string{
+		"APP_SERVER_PORT": "8080",
+		"LOG_FILE":        "/var/log/app.log",
+	}
+	lookup := func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+
+	var config Config
+	origins, err := ini.NewLoader(
+		ini.Defaults(),
+		ini.File(fsys, "etc/app.conf"),
+		ini.OptionalFile(fsys, "home/.app.conf"),
+		ini.OptionalFile(fsys, "app.conf"),
+		ini.Env("APP", lookup),
+	).Add(
+		mapSource{"log": {"level": "debug"}},
+		ini.Overrides("server.host=127.0.0.1"),
+	).Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Server.Host != "127.0.0.1" ||
+		config.Server.Port != 8080 ||
+		len(config.Server.Aliases) != 2 ||
+		config.Log.Level != "debug" ||
+		config.Log.File != "/var/log/app.log" {
+		t.Errorf("unexpected config: %+v", config)
+	}
+
+	expect := map[[2]string]string{
+		{"server", "host"}:    "command line",
+		{"server", "port"}:    "$APP_SERVER_PORT",
+		{"server", "aliases"}: "home/.app.conf",
+		{"log", "level"}:      "test",
+		{"log", "file"}:       "$LOG_FILE",
+	}
+	for key, origin := range expect {
+		if got := origins.File(key[0], key[1]); got != origin {
+			t.Errorf("unexpected origin of %s.%s: %q", key[0], key[1], got)
+		}
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		var config Config
+		origins, err := ini.NewLoader(ini.Defaults()).Load(&config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if config.Server.Port != 80 || origins.File("server", "port") != "defaults" {
+			t.Errorf("unexpected config: %+v", config)
+		}
+	})
+
+	t.Run("decoder settings", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"app.conf": {Data: []byte("[Server]\nHost=${log.level}.example.com\n" +
+				"[server:prod]\nport=443\n")},
+		}
+		var config Config
+		d := ini.NewDecoder(nil).Profile("prod").Interpolate(true).CaseInsensitive(true)
+		_, err := ini.NewLoader(
+			ini.File(fsys, "app.conf"),
+			ini.Overrides("log.level=debug"),
+		).Decoder(d).Load(&config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if config.Server.Host != "debug.example.com" || config.Server.Port != 443 {
+			t.Errorf("unexpected config: %+v", config)
+		}
+	})
+
+	t.Run("layers", func(t *testing.T) {
+		type Server struct {
+			Host string `ini:"host"`
+			Port int    `ini:"port"`
+			Name string `ini:"name" alias:"title"`
+		}
+		var config struct {
+			Base Server `ini:"base"`
+			DB   Server `ini:"db"`
+		}
+		fsys := fstest.MapFS{
+			"a.ini": {Data: []byte("[DEFAULT]\nhost=a\n[base]\nport=1\ntitle=x\n[db : base]\n")},
+			"b.ini": {Data: []byte("[DEFAULT]\nhost=b\n")},
+		}
+		origins, err := ini.NewLoader(
+			ini.File(fsys, "a.ini"),
+			ini.File(fsys, "b.ini"),
+			ini.Overrides("base.port=2"),
+		).Load(&config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if config.Base != (Server{"b", 2, "x"}) || config.DB != (Server{"b", 2, "x"}) {
+			t.Errorf("unexpected config: %+v", config)
+		}
+		if origins.File("db", "host") != "b.ini" ||
+			origins.File("db", "port") != "command line" ||
+			origins.File("base", "name") != "a.ini" {
+			t.Errorf("unexpected origins: %v", origins)
+		}
+	})
+
+	t.Run("custom raw source", func(t *testing.T) {
+		var config Config
+		source := sourceFunc(func([]ini.Section) ([]ini.KeyValue, error) {
+			return []ini.KeyValue{
+				{Section: "server", Key: "aliases", Value: "'a', b ; comment", Raw: true},
+				{Section: "server", Key: "host", Value: "'x'"},
+				{Section: "log", Key: "level", Value: "'debug'", Raw: true},
+			}, nil
+		})
+		if _, err := ini.NewLoader(source).Load(&config); err != nil {
+			t.Fatal(err)
+		}
+		if len(config.Server.Aliases) != 2 || config.Server.Host != "'x'" ||
+			config.Log.Level != "debug" {
+			t.Errorf("unexpected config: %+v", config)
+		}
+	})
+
+	t.Run("values as written", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"app.conf": {Data: []byte("[a]\nx='1024'\ny=1024\nz='a', \"b\" ; comment\n")},
+		}
+
+		m := map[string]map[string]any{}
+		d := ini.NewDecoder(nil).InferTypes(true)
+		if _, err := ini.NewLoader(ini.File(fsys, "app.conf")).Decoder(d).Load(&m); err != nil {
+			t.Fatal(err)
+		}
+		if m["a"]["x"] != "1024" || m["a"]["y"] != int64(1024) {
+			t.Errorf("unexpected map: %#v", m)
+		}
+
+		var raw struct {
+			A struct {
+				Z ini.RawValue `ini:"z"`
+			} `ini:"a"`
+		}
+		if _, err := ini.NewLoader(ini.File(fsys, "app.conf")).Load(&raw); err != nil {
+			t.Fatal(err)
+		}
+		if raw.A.Z.Text != `'a', "b"` || raw.A.Z.Line != 4 {
+			t.Errorf("unexpected raw value: %+v", raw.A.Z)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := map[string]ini.Source{
+			"open missing.conf: file does not exist":                    ini.File(fsys, "missing.conf"),
+			"home/.app.conf.bad: unexpected character '?' at 2:1":       ini.File(fsys, "home/.app.conf.bad"),
+			"invalid override 'server=1': expected 'section.key=value'": ini.Overrides("server=1"),
+			"invalid override 'server.port': expected 'section.key=value'": ini.Overrides(
+				"server.port",
+			),
+			"unknown section named 'db'": ini.Overrides("db.host=localhost"),
+		}
+		for expect, source := range tests {
+			var config Config
+			_, err := ini.NewLoader(source).Load(&config)
+			if err == nil || err.Error() != expect {
+				t.Errorf("unexpected error\nexpect: %s\ngot:    %v", expect, err)
+			}
+		}
+	})
+}
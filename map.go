@@ -0,0 +1,197 @@
+package ini
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// UnmarshalMap deserializes an INI file without a schema.
+//
+// Values are converted to bool, int64, float64 or []any when they look like
+// one (see [Decoder.InferTypes]), other values are kept as strings. Use
+// a [Decoder] with a *[]Section target to preserve the order of sections
+// and keys.
+func UnmarshalMap(data []byte) (map[string]map[string]any, error) {
+	m := map[string]map[string]any{}
+	d := NewDecoder(bytes.NewReader(data)).InferTypes(true)
+	if err := d.Decode(&m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+var tAny = reflect.TypeFor[any]()
+
+// isUntypedTarget reports whether the value is a pointer to a map or
+// to a []Section, which are filled with every section of the file. Maps
+// of variants (see [RegisterVariant]) are decoded as typed values.
+func isUntypedTarget(v reflect.Value) bool {
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return false
+	}
+	t := v.Type().Elem()
+	if t.Implements(tMarshaler) || v.Type().Implements(tMarshaler) {
+		return false
+	}
+	return t == tSections ||
+		t.Kind() == reflect.Map && t.Key().Kind() == reflect.String &&
+			len(variantsOf(t.Elem())) == 0
+}
+
+func (d *Decoder) decodeUntyped(doc *document, v reflect.Value) error {
+	if v.Type() == tSections {
+		sections := v.Interface().([]Section)
+		for _, s := range doc.sections {
+			sections = d.appendSection(sections, s)
+		}
+		v.Set(reflect.ValueOf(sections))
+		return nil
+	}
+
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(v.Type()))
+	}
+
+	for _, s := range doc.sections {
+		name := reflect.ValueOf(s.name).Convert(v.Type().Key())
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if existing := v.MapIndex(name); existing.IsValid() {
+			elem.Set(existing)
+		}
+
+		if elem.Kind() == reflect.Interface {
+			if elem.IsNil() || elem.Elem().Kind() != reflect.Map {
+				elem.Set(reflect.ValueOf(map[string]any{}))
+			}
+			elem = elem.Elem()
+		}
+
+		if elem.Kind() == reflect.Map && elem.IsNil() {
+			elem.Set(reflect.MakeMap(elem.Type()))
+		}
+
+		section, err := d.tree.sectionOf(
+			elem,
+			nil,
+			reflect.StructField{},
+			flags{key: s.name},
+		)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range s.keys {
+			if _, err := d.decodeKey(&section, key); err != nil {
+				return err
+			}
+		}
+
+		v.SetMapIndex(name, elem)
+	}
+
+	return nil
+}
+
+func (d *Decoder) appendSection(sections []Section, s *docSection) []Section {
+	section := findSection(sections, s.name)
+	if section == nil {
+		sections = append(sections, Section{Name: s.name})
+		section = &sections[len(sections)-1]
+	}
+
+	for _, key := range s.keys {
+		value := reflect.New(tAny).Elem()
+		value.Set(reflect.ValueOf(d.untyped(key.value)))
+
+		if i := indexOfField(section.Fields, key.name); i >= 0 {
+			section.Fields[i].Value = value
+		} else {
+			section.Fields = append(section.Fields, Field{
+				Name:  key.name,
+				Value: value,
+			})
+		}
+	}
+
+	return sections
+}
+
+func (d *Decoder) decodeMapEntry(m reflect.Value, key docKey) error {
+	if m.IsNil() {
+		if !m.CanSet() {
+			return fmt.Errorf("cannot store key '%s' in a nil map", key.name)
+		}
+		m.Set(reflect.MakeMap(m.Type()))
+	}
+
+	t := m.Type().Elem()
+	value := reflect.New(t).Elem()
+
+	if t.Kind() == reflect.Interface && t.NumMethod() == 0 {
+		value.Set(reflect.ValueOf(d.untyped(key.value)))
+	} else if err := decodeValue(key, value); err != nil {
+		return err
+	}
+
+	m.SetMapIndex(reflect.ValueOf(key.name).Convert(m.Type().Key()), value)
+	return nil
+}
+
+// untyped returns the value as a string or, if type inference is enabled,
+// as the type the value looks like.
+func (d *Decoder) untyped(value docValue) any {
+	if !d.inferTypes {
+		return value.text
+	}
+
+	if value.list {
+		items := strings.Split(value.text, ",")
+		list := make([]any, len(items))
+		for i, item := range items {
+			if value.isQuoted(i) {
+				list[i] = item
+			} else {
+				list[i] = inferType(item)
+			}
+		}
+		return list
+	}
+
+	if value.isQuoted(0) {
+		return value.text
+	}
+
+	return inferType(value.text)
+}
+
+func inferType(s string) any {
+	switch {
+	case strings.EqualFold(s, "true"):
+		return true
+
+	case strings.EqualFold(s, "false"):
+		return false
+	}
+
+	if x, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return x
+	}
+
+	if x, err := strconv.ParseFloat(s, 64); err == nil {
+		return x
+	}
+
+	return s
+}
+
+func indexOfField(fields []Field, name string) int {
+	for i := range fields {
+		if fields[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,85 @@
+package ini_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/saffage/go-ini"
+)
+
+const untypedData = `[Video]
+width=1024
+scale = 1.5
+fullscreen=true
+
+; comment
+[Paths]
+root=/usr/local
+name='1024'
+list=1, 2, three
+mixed=1, '2'
+`
+
+func TestUnmarshalMap(t *testing.T) {
+	m, err := ini.UnmarshalMap([]byte(untypedData))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := map[string]map[string]any{
+		"Video": {
+			"width":      int64(1024),
+			"scale":      1.5,
+			"fullscreen": true,
+		},
+		"Paths": {
+			"root":  "/usr/local",
+			"name":  "1024",
+			"list":  []any{int64(1), int64(2), "three"},
+			"mixed": []any{int64(1), "2"},
+		},
+	}
+
+	if !reflect.DeepEqual(m, expect) {
+		t.Errorf("unexpected map\nexpect: %#v\ngot:    %#v", expect, m)
+	}
+}
+
+func TestDecodeUntyped(t *testing.T) {
+	t.Run("raw strings", func(t *testing.T) {
+		m := map[string]any{}
+		if err := ini.Unmarshal([]byte(untypedData), &m); err != nil {
+			t.Fatal(err)
+		}
+		video, ok := m["Video"].(map[string]any)
+		if !ok || video["width"] != "1024" || video["fullscreen"] != "true" {
+			t.Errorf("unexpected section: %#v", m["Video"])
+		}
+	})
+	t.Run("ordered", func(t *testing.T) {
+		var sections []ini.Section
+		d := ini.NewDecoder(bytes.NewReader([]byte(untypedData))).InferTypes(true)
+		if err := d.Decode(&sections); err != nil {
+			t.Fatal(err)
+		}
+		const expect = "[Video]\nwidth=1024\nscale=1.5\nfullscreen=true\n" +
+			"[Paths]\nroot='/usr/local'\nname='1024'\nlist=1,2,'three'\nmixed=1,'2'\n"
+		testMarshal(t, expect, sections)
+	})
+	t.Run("map section", func(t *testing.T) {
+		var file struct {
+			Video map[string]string
+			Paths map[string]any
+		}
+		if err := ini.Unmarshal([]byte(untypedData), &file); err != nil {
+			t.Fatal(err)
+		}
+		if file.Video["width"] != "1024" || file.Video["scale"] != "1.5" {
+			t.Errorf("unexpected section: %#v", file.Video)
+		}
+		if file.Paths["root"] != "/usr/local" {
+			t.Errorf("unexpected section: %#v", file.Paths)
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package ini
+
+import "reflect"
+
+// Key describes a key of a decoded file or a field of the decoded value.
+type Key struct {
+	Section string
+	Name    string
+	File    string // Name of the file or "$NAME" of an environment variable.
+	Line    int    // Zero if the key is not written in a file.
+	Column  int    // Zero if the key is not written in a file.
+
+	// Inherited is the name of the section the key is inherited from,
+	// see [Unmarshal]. Empty if the key is written in its own section.
+	Inherited string
+}
+
+// MetaData describes how a file is decoded, see [Decoder.DecodeWithMeta].
+type MetaData struct {
+	keys      []Key
+	undecoded []Key
+	untouched []Key
+	defaulted []Key
+}
+
+// DecodeWithMeta is like [Decoder.Decode], but it also returns
+// the metadata of the decoded keys. Keys of sections that do not exist in
+// the value are reported as undecoded instead of failing.
+//
+// The metadata is returned even if the value fails to validate.
+func (d *Decoder) DecodeWithMeta(value any) (MetaData, error) {
+	meta := MetaData{}
+
+	if !isUntypedTarget(reflect.ValueOf(value)) {
+		// Report unsupported values before reading the input.
+		if _, err := d.tree.sectionsOf(value); err != nil {
+			return meta, err
+		}
+	}
+
+	doc, err := d.read()
+	if err != nil {
+		return meta, err
+	}
+
+	err = d.decodeDocument(doc, value, &meta, nil)
+	return meta, err
+}
+
+// Keys returns every key of the file in the order of sections and keys.
+func (m *MetaData) Keys() []Key {
+	return m.keys
+}
+
+// Key looks for a key of the file.
+func (m *MetaData) Key(section, name string) (Key, bool) {
+	for _, key := range m.keys {
+		if key.Section == section && key.Name == name {
+			return key, true
+		}
+	}
+	return Key{}, false
+}
+
+// IsDefined reports whether the key is present in the file.
+func (m *MetaData) IsDefined(section, name string) bool {
+	_, ok := m.Key(section, name)
+	return ok
+}
+
+// Undecoded returns the keys of the file that are not mapped to any field.
+// Inherited keys are reported only in the section they are written in.
+func (m *MetaData) Undecoded() []Key {
+	return m.undecoded
+}
+
+// Untouched returns the fields of the value that are left untouched
+// because their keys are missing in the file and have no default value.
+// Positions of such keys are always zero.
+func (m *MetaData) Untouched() []Key {
+	return m.untouched
+}
+
+// Defaulted returns the fields of the value whose keys are missing in
+// the file and are set to their default values. Positions of such keys
+// are always zero.
+func (m *MetaData) Defaulted() []Key {
+	return m.defaulted
+}
+
+// collect adds the keys of the document and the fields missing in it
+// to the metadata. It does nothing if the metadata is nil.
+func (m *MetaData) collect(doc *document, sections []Section) {
+	if m == nil {
+		return
+	}
+
+	for _, s := range doc.sections {
+		for _, key := range s.keys {
+			m.keys = append(m.keys, metaKey(s, key))
+		}
+	}
+
+	for _, section := range sections {
+		s := doc.section(section.Name)
+		for _, field := range section.Fields {
+			if s != nil {
+				if _, present := s.key(field.Name); present {
+					continue
+				}
+			}
+			key := Key{Section: section.Name, Name: field.Name}
+			if field.Default != "" && (s != nil || !section.null) {
+				m.defaulted = append(m.defaulted, key)
+			} else {
+				m.untouched = append(m.untouched, key)
+			}
+		}
+	}
+}
+
+func metaKey(s *docSection, key docKey) Key {
+	return Key{
+		Section:   s.name,
+		Name:      key.name,
+		File:      key.file,
+		Line:      int(key.line),
+		Column:    int(key.column),
+		Inherited: key.inherited,
+	}
+}
@@ -0,0 +1,103 @@
+package ini_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/saffage/go-ini"
+)
+
+func TestDecodeWithMeta(t *testing.T) {
+	type Server struct {
+		Host    string `ini:"host"`
+		Port    int    `ini:"port,default=8080"`
+		Timeout int    `ini:"timeout"`
+		Debug   bool   `ini:"debug"`
+	}
+	type Config struct {
+		Base  Server  `ini:"base"`
+		Prod  Server  `ini:"prod"`
+		Spare *Server `ini:"spare"`
+	}
+
+	const data = `[DEFAULT]
+timeout=30
+
+[base]
+host=localhost
+hots=example.com
+
+[prod : base]
+port=80
+
+[legacy]
+enabled=true
+`
+
+	var config Config
+	meta, err := ini.NewDecoder(strings.NewReader(data)).DecodeWithMeta(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Prod != (Server{"localhost", 80, 30, false}) {
+		t.Errorf("unexpected config: %+v", config)
+	}
+
+	expectKeys := []ini.Key{
+		{Section: "base", Name: "host", Line: 5, Column: 1},
+		{Section: "base", Name: "hots", Line: 6, Column: 1},
+		{Section: "base", Name: "timeout", Line: 2, Column: 1, Inherited: "DEFAULT"},
+		{Section: "prod", Name: "port", Line: 9, Column: 1},
+		{Section: "prod", Name: "host", Line: 5, Column: 1, Inherited: "base"},
+		{Section: "prod", Name: "hots", Line: 6, Column: 1, Inherited: "base"},
+		{Section: "prod", Name: "timeout", Line: 2, Column: 1, Inherited: "DEFAULT"},
+		{Section: "legacy", Name: "enabled", Line: 12, Column: 1},
+		{Section: "legacy", Name: "timeout", Line: 2, Column: 1, Inherited: "DEFAULT"},
+	}
+	if !reflect.DeepEqual(meta.Keys(), expectKeys) {
+		t.Errorf("unexpected keys:\n%+v", meta.Keys())
+	}
+
+	expectUndecoded := []ini.Key{
+		{Section: "base", Name: "hots", Line: 6, Column: 1},
+		{Section: "legacy", Name: "enabled", Line: 12, Column: 1},
+	}
+	if !reflect.DeepEqual(meta.Undecoded(), expectUndecoded) {
+		t.Errorf("unexpected undecoded keys:\n%+v", meta.Undecoded())
+	}
+
+	expectUntouched := []ini.Key{
+		{Section: "base", Name: "debug"},
+		{Section: "prod", Name: "debug"},
+		{Section: "spare", Name: "host"},
+		{Section: "spare", Name: "port"},
+		{Section: "spare", Name: "timeout"},
+		{Section: "spare", Name: "debug"},
+	}
+	if !reflect.DeepEqual(meta.Untouched(), expectUntouched) {
+		t.Errorf("unexpected untouched keys:\n%+v", meta.Untouched())
+	}
+
+	expectDefaulted := []ini.Key{{Section: "base", Name: "port"}}
+	if !reflect.DeepEqual(meta.Defaulted(), expectDefaulted) {
+		t.Errorf("unexpected defaulted keys:\n%+v", meta.Defaulted())
+	}
+
+	if key, ok := meta.Key("prod", "port"); !ok || key.Line != 9 {
+		t.Errorf("unexpected key: %+v", key)
+	}
+	if !meta.IsDefined("base", "host") || meta.IsDefined("base", "port") {
+		t.Error("unexpected defined keys")
+	}
+
+	t.Run("decode", func(t *testing.T) {
+		var config Config
+		err := ini.Unmarshal([]byte(data), &config)
+		const expect = "unknown section named 'legacy'"
+		if err == nil || err.Error() != expect {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package ini
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Naming sets the function that names sections and keys of struct fields
+// without a name in their tag, for example [SnakeCase]. By default, the name
+// of the Go field is used as is.
+func (e *Encoder) Naming(naming func(name string) string) *Encoder {
+	e.tree.naming = naming
+	return e
+}
+
+// Naming sets the function that names sections and keys of struct fields
+// without a name in their tag, for example [SnakeCase]. By default, the name
+// of the Go field is used as is.
+func (d *Decoder) Naming(naming func(name string) string) *Decoder {
+	d.tree.naming = naming
+	return d
+}
+
+// FallbackTag sets the key of a struct tag, for example "json", that names
+// fields without the 'ini' tag. The name and the omitempty option are read
+// from the tag, fields named "-" are ignored. Fields without a name in
+// either tag are named by [Encoder.Naming].
+func (e *Encoder) FallbackTag(key string) *Encoder {
+	e.tree.tagKey = key
+	return e
+}
+
+// FallbackTag sets the key of a struct tag, for example "json", that names
+// fields without the 'ini' tag. The name and the omitempty option are read
+// from the tag, fields named "-" are ignored. Fields without a name in
+// either tag are named by [Decoder.Naming].
+func (d *Decoder) FallbackTag(key string) *Decoder {
+	d.tree.tagKey = key
+	return d
+}
+
+// SnakeCase converts a Go name to snake case: "FullScreen" becomes
+// "full_screen" and "HTTPServer" becomes "http_server".
+func SnakeCase(name string) string {
+	return strings.Join(words(name), "_")
+}
+
+// KebabCase converts a Go name to kebab case: "FullScreen" becomes
+// "full-screen" and "HTTPServer" becomes "http-server".
+func KebabCase(name string) string {
+	return strings.Join(words(name), "-")
+}
+
+// LowerCase converts a Go name to lower case: "FullScreen" becomes
+// "fullscreen".
+func LowerCase(name string) string {
+	return strings.ToLower(name)
+}
+
+// CamelCase converts a Go name to camel case: "FullScreen" becomes
+// "fullScreen" and "HTTPServer" becomes "httpServer".
+func CamelCase(name string) string {
+	words := words(name)
+	for i := 1; i < len(words); i++ {
+		r := []rune(words[i])
+		r[0] = unicode.ToUpper(r[0])
+		words[i] = string(r)
+	}
+	return strings.Join(words, "")
+}
+
+// words splits a Go name into lower-cased words. A word starts at an upper
+// case letter that follows a lower case letter or a digit, and at the last
+// upper case letter of an acronym followed by a lower case letter.
+// Underscores separate words as well.
+func words(name string) []string {
+	var words []string
+	r := []rune(name)
+	start := 0
+
+	for i := range r {
+		switch {
+		case r[i] == '_':
+			if i > start {
+				words = append(words, string(r[start:i]))
+			}
+			start = i + 1
+
+		case i > start && unicode.IsUpper(r[i]):
+			prev := r[i-1]
+			nextLower := i+1 < len(r) && unicode.IsLower(r[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+				unicode.IsUpper(prev) && nextLower {
+				words = append(words, string(r[start:i]))
+				start = i
+			}
+		}
+	}
+	if start < len(r) {
+		words = append(words, string(r[start:]))
+	}
+
+	for i := range words {
+		words[i] = strings.ToLower(words[i])
+	}
+	return words
+}
@@ -0,0 +1,106 @@
+package ini_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/saffage/go-ini"
+)
+
+func TestNamingStrategies(t *testing.T) {
+	tests := map[string][4]string{
+		"FullScreen": {"full_screen", "full-screen", "fullscreen", "fullScreen"},
+		"HTTPServer": {"http_server", "http-server", "httpserver", "httpServer"},
+		"UserID":     {"user_id", "user-id", "userid", "userId"},
+		"Port2Max":   {"port2_max", "port2-max", "port2max", "port2Max"},
+		"Max_Count":  {"max_count", "max-count", "max_count", "maxCount"},
+		"X":          {"x", "x", "x", "x"},
+	}
+	for name, expect := range tests {
+		got := [4]string{
+			ini.SnakeCase(name),
+			ini.KebabCase(name),
+			ini.LowerCase(name),
+			ini.CamelCase(name),
+		}
+		if got != expect {
+			t.Errorf("unexpected names of %s: %q", name, got)
+		}
+	}
+
+	type Window struct {
+		FullScreen  bool
+		WindowTitle string `ini:",omitempty"`
+		Width       int    `ini:"w"`
+	}
+	type file struct {
+		MainWindow Window
+	}
+
+	b := strings.Builder{}
+	value := file{MainWindow: Window{FullScreen: true, Width: 800}}
+	if err := ini.NewEncoder(&b).Naming(ini.SnakeCase).Encode(value); err != nil {
+		t.Fatal(err)
+	}
+	const expect = "[main_window]\nfull_screen=true\nw=800\n"
+	if b.String() != expect {
+		t.Errorf("unexpected result\nexpect: %q\ngot:    %q", expect, b.String())
+	}
+
+	var f file
+	err := ini.NewDecoder(strings.NewReader(expect + "window_title=main\n")).
+		Naming(ini.SnakeCase).
+		Decode(&f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.MainWindow != (Window{FullScreen: true, WindowTitle: "main", Width: 800}) {
+		t.Errorf("unexpected value: %+v", f)
+	}
+}
+
+func TestFallbackTag(t *testing.T) {
+	type Server struct {
+		Host     string `json:"host"`
+		Port     int    `json:"port,omitempty"`
+		Password string `json:"-"`
+		Dash     string `json:"-,"`
+		Timeout  int    `json:",omitempty"`
+		Debug    bool   `json:"debug" ini:"verbose"`
+		Mode     string `yaml:"mode"`
+	}
+	type file struct {
+		Server Server `json:"server"`
+	}
+
+	value := file{Server: Server{
+		Host:     "localhost",
+		Password: "secret",
+		Dash:     "dash",
+		Debug:    true,
+	}}
+
+	b := strings.Builder{}
+	err := ini.NewEncoder(&b).FallbackTag("json").Naming(ini.SnakeCase).Encode(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const expect = "[server]\nhost='localhost'\n-='dash'\nverbose=true\nmode=''\n"
+	if b.String() != expect {
+		t.Errorf("unexpected result\nexpect: %q\ngot:    %q", expect, b.String())
+	}
+
+	var f file
+	const data = "[server]\nhost='localhost'\nverbose=true\nport=80\ntimeout=5\n"
+	err = ini.NewDecoder(strings.NewReader(data)).
+		FallbackTag("json").
+		Naming(ini.SnakeCase).
+		Decode(&f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectValue := Server{Host: "localhost", Port: 80, Timeout: 5, Debug: true}
+	if f.Server != expectValue {
+		t.Errorf("unexpected value: %+v", f.Server)
+	}
+}
@@ -0,0 +1,137 @@
+package ini
+
+import (
+	"slices"
+	"strings"
+)
+
+// CaseInsensitive allows the decoder to match names of sections and keys
+// regardless of their case, as Windows INI files do. Sections and keys
+// that match no field, such as those of maps, are merged under the name
+// they are written with first.
+func (d *Decoder) CaseInsensitive(flag bool) *Decoder {
+	d.foldCase = flag
+	return d
+}
+
+// NormalizeNames sets the function applied to names of sections and keys
+// before they are matched: names of the file are matched with names of
+// the value if they are equal after normalization. It is applied before
+// case folding (see [Decoder.CaseInsensitive]). Names of parent sections,
+// profiles and the "DEFAULT" section are matched the same way.
+//
+// For example, [IgnoreSeparators] treats "full-screen", "full_screen" and
+// "fullscreen" as the same name.
+func (d *Decoder) NormalizeNames(normalize func(name string) string) *Decoder {
+	d.normalize = normalize
+	return d
+}
+
+// IgnoreSeparators removes hyphens, underscores and spaces from the name.
+// It can be used with [Decoder.NormalizeNames].
+func IgnoreSeparators(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || r == ' ' {
+			return -1
+		}
+		return r
+	}, name)
+}
+
+// normalizeName returns the name used to match names of sections and keys.
+func (d *Decoder) normalizeName(name string) string {
+	if d.normalize != nil {
+		name = d.normalize(name)
+	}
+	if d.foldCase {
+		name = strings.ToLower(name)
+	}
+	return name
+}
+
+// normalizeNames renames sections and keys of the document to the names
+// of the sections and fields they match. Sections and keys that match
+// the same name are merged, later keys replace earlier ones. Sections and
+// keys that match no name, as well as every section and key of untyped
+// targets (sections are nil), are named as they are written first.
+func (d *Decoder) normalizeNames(doc *document, sections []Section) {
+	if d.normalize == nil && !d.foldCase {
+		return
+	}
+
+	merged := make([]*docSection, 0, len(doc.sections))
+	for _, s := range doc.sections {
+		section := Section{}
+		for _, candidate := range sections {
+			if d.normalizeName(candidate.Name) == d.normalizeName(s.name) {
+				section = candidate
+				s.name = section.Name
+				break
+			}
+		}
+		d.normalizeKeys(s, section)
+
+		i := slices.IndexFunc(merged, func(m *docSection) bool {
+			return d.normalizeName(m.name) == d.normalizeName(s.name)
+		})
+		if i < 0 {
+			merged = append(merged, s)
+			continue
+		}
+		for _, key := range s.keys {
+			merged[i].set(d.renameKey(merged[i].keys, key))
+		}
+	}
+
+	doc.sections = merged
+}
+
+// normalizeKeys renames keys of the document section to the names or
+// aliases of the fields they match.
+func (d *Decoder) normalizeKeys(s *docSection, section Section) {
+	keys := make([]docKey, 0, len(s.keys))
+	for _, key := range s.keys {
+		if name, ok := d.matchField(section, key.name); ok {
+			key.name = name
+		}
+		key = d.renameKey(keys, key)
+
+		i := slices.IndexFunc(keys, func(k docKey) bool {
+			return k.name == key.name
+		})
+		if i < 0 {
+			keys = append(keys, key)
+		} else {
+			keys[i] = key
+		}
+	}
+	s.keys = keys
+}
+
+// renameKey names the key as the key of keys with the same normalized
+// name, if any.
+func (d *Decoder) renameKey(keys []docKey, key docKey) docKey {
+	name := d.normalizeName(key.name)
+	for _, k := range keys {
+		if d.normalizeName(k.name) == name {
+			key.name = k.name
+			break
+		}
+	}
+	return key
+}
+
+func (d *Decoder) matchField(section Section, name string) (string, bool) {
+	name = d.normalizeName(name)
+	for _, field := range section.Fields {
+		if d.normalizeName(field.Name) == name {
+			return field.Name, true
+		}
+		for _, alias := range field.Aliases {
+			if d.normalizeName(alias) == name {
+				return alias, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,100 @@
+package ini_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/saffage/go-ini"
+)
+
+func TestNormalizeNames(t *testing.T) {
+	type Window struct {
+		FullScreen bool   `ini:"full_screen"`
+		Title      string `ini:"title" alias:"caption"`
+	}
+	type file struct {
+		Window Window            `ini:"Window"`
+		Extra  map[string]string `ini:"extra"`
+	}
+
+	t.Run("case insensitive", func(t *testing.T) {
+		const data = "[WINDOW]\nFULL_SCREEN=true\nTitle=a\n[window]\ntitle=b\n[Extra]\nKey=value\n"
+
+		var f file
+		err := ini.NewDecoder(strings.NewReader(data)).CaseInsensitive(true).Decode(&f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect := file{
+			Window: Window{FullScreen: true, Title: "b"},
+			Extra:  map[string]string{"Key": "value"},
+		}
+		if !reflect.DeepEqual(f, expect) {
+			t.Errorf("unexpected value: %+v", f)
+		}
+
+		err = ini.Unmarshal([]byte(data), &f)
+		const expectErr = "unknown section named 'WINDOW'"
+		if err == nil || err.Error() != expectErr {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("normalizer", func(t *testing.T) {
+		const data = "[Window]\nFull-Screen=true\nCAPTION=a\n"
+
+		var warnings []string
+		var f file
+		err := ini.NewDecoder(strings.NewReader(data)).
+			NormalizeNames(ini.IgnoreSeparators).
+			CaseInsensitive(true).
+			Warnings(func(w ini.Warning) { warnings = append(warnings, w.String()) }).
+			Decode(&f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Window != (Window{FullScreen: true, Title: "a"}) {
+			t.Errorf("unexpected value: %+v", f.Window)
+		}
+		const expect = "[Window] caption (line 3): 'caption' is an old name of 'title'"
+		if len(warnings) != 1 || warnings[0] != expect {
+			t.Errorf("unexpected warnings: %q", warnings)
+		}
+	})
+
+	t.Run("map", func(t *testing.T) {
+		const data = "[A]\nx=1\n[a]\nX=2\ny=3\n"
+
+		m := map[string]map[string]string{}
+		err := ini.NewDecoder(strings.NewReader(data)).CaseInsensitive(true).Decode(&m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect := map[string]map[string]string{"A": {"x": "2", "y": "3"}}
+		if !reflect.DeepEqual(m, expect) {
+			t.Errorf("unexpected value: %v", m)
+		}
+	})
+
+	t.Run("inheritance", func(t *testing.T) {
+		type Server struct {
+			Host    string `ini:"host"`
+			Port    int    `ini:"port"`
+			Timeout int    `ini:"timeout"`
+		}
+		var f struct {
+			Base Server `ini:"base"`
+			DB   Server `ini:"db"`
+		}
+		const data = "[default]\nTIMEOUT=5\n[Base]\nhost=x\nport=1\n[DB : BASE]\nPort=2\n"
+
+		err := ini.NewDecoder(strings.NewReader(data)).CaseInsensitive(true).Decode(&f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Base != (Server{"x", 1, 5}) || f.DB != (Server{"x", 2, 5}) {
+			t.Errorf("unexpected value: %+v", f)
+		}
+	})
+}
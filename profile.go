@@ -0,0 +1,130 @@
+package ini
+
+import (
+	"bytes"
+	"slices"
+)
+
+// Profile selects the profile whose sections overlay their base sections.
+// The profile is declared as well, see [Decoder.Profiles].
+//
+// A profile section is written under the header '[name : profile]', where
+// name is the base section. Keys of the selected profile replace keys of
+// the base section, sections of other declared profiles are ignored:
+//
+//	[database]
+//	host=localhost
+//	[database:staging]
+//	host=staging.example.com
+//	[database:prod]
+//	host=db.example.com
+//
+//	d := ini.NewDecoder(r).Profiles("staging", "prod").Profile("prod")
+func (d *Decoder) Profile(name string) *Decoder {
+	d.profile = name
+	return d
+}
+
+// Profiles declares the names of profiles written in the file. A header
+// '[name : parent]' denotes a profile section if parent is a declared
+// profile, and section inheritance otherwise (see [Unmarshal]). Sections
+// of profiles that are not selected by [Decoder.Profile] are ignored.
+func (d *Decoder) Profiles(names ...string) *Decoder {
+	d.profiles = append(d.profiles, names...)
+	return d
+}
+
+// inheritOptions returns the options of [document.inherit] set by
+// the decoder.
+func (d *Decoder) inheritOptions() inheritOptions {
+	return inheritOptions{
+		profile:   d.profile,
+		profiles:  d.profiles,
+		normalize: d.normalizeName,
+	}
+}
+
+// MarshalProfile serializes the keys of value that differ from the keys of
+// base as sections of the profile. Sections without such keys are omitted.
+//
+// More information can be found in the [Decoder.Profile] documentation.
+func MarshalProfile(profile string, base, value any) ([]byte, error) {
+	buf := bytes.Buffer{}
+	e := Encoder{}
+	e.Reset(&buf)
+	if err := e.EncodeProfile(profile, base, value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// EncodeProfile writes the keys of value that differ from the keys of base
+// as sections of the profile.
+//
+// More information can be found in the [MarshalProfile] function
+// documentation.
+func (e *Encoder) EncodeProfile(profile string, base, value any) error {
+	baseSections, err := e.tree.sectionsOf(base)
+	if err != nil {
+		return err
+	}
+
+	sections, err := e.tree.sectionsOf(value)
+	if err != nil {
+		return err
+	}
+
+	diff := make([]Section, 0, len(sections))
+	for _, section := range sections {
+		if section.null {
+			continue
+		}
+
+		baseSection := findSection(baseSections, section.Name)
+		fields := make([]Field, 0, len(section.Fields))
+
+		for _, field := range section.Fields {
+			changed, err := e.fieldChanged(baseSection, field)
+			if err != nil {
+				return err
+			}
+			if changed {
+				field.OmitEmpty = false
+				fields = append(fields, field)
+			}
+		}
+
+		if len(fields) > 0 {
+			section.Name += ":" + profile
+			section.Fields = fields
+			section.OmitEmpty = false
+			diff = append(diff, section)
+		}
+	}
+
+	return e.Encode(diff)
+}
+
+// fieldChanged reports whether the field is encoded differently than
+// the field of the same name of the base section.
+func (e *Encoder) fieldChanged(base *Section, field Field) (bool, error) {
+	if base == nil || base.null {
+		return true, nil
+	}
+
+	baseField, ok := base.Field(field.Name)
+	if !ok || !baseField.Value.IsValid() {
+		return true, nil
+	}
+
+	text, err := encode(field.Value, &e.opts)
+	if err != nil {
+		return false, err
+	}
+	baseText, err := encode(baseField.Value, &e.opts)
+	if err != nil {
+		return false, err
+	}
+
+	return !slices.Equal(text, baseText), nil
+}
@@ -0,0 +1,129 @@
+package ini_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/saffage/go-ini"
+)
+
+func TestProfile(t *testing.T) {
+	type Database struct {
+		Host string `ini:"host"`
+		Port int    `ini:"port"`
+		User string `ini:"user,omitempty"`
+	}
+	type Config struct {
+		Database Database `ini:"database"`
+		Cache    Database `ini:"cache"`
+	}
+
+	const data = `
+[database:prod]
+host=db.example.com
+
+[database]
+host=localhost
+port=5432
+
+[database:staging]
+host=staging.example.com
+port=6543
+
+[cache : database]
+port=6379
+`
+
+	tests := map[string]Config{
+		"": {
+			Database: Database{Host: "localhost", Port: 5432},
+			Cache:    Database{Host: "localhost", Port: 6379},
+		},
+		"prod": {
+			Database: Database{Host: "db.example.com", Port: 5432},
+			Cache:    Database{Host: "db.example.com", Port: 6379},
+		},
+		"staging": {
+			Database: Database{Host: "staging.example.com", Port: 6543},
+			Cache:    Database{Host: "staging.example.com", Port: 6379},
+		},
+		"dev": {
+			Database: Database{Host: "localhost", Port: 5432},
+			Cache:    Database{Host: "localhost", Port: 6379},
+		},
+	}
+	for profile, expect := range tests {
+		var config Config
+		err := ini.NewDecoder(strings.NewReader(data)).
+			Profiles("staging", "prod").
+			Profile(profile).
+			Decode(&config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if config != expect {
+			t.Errorf("unexpected config for profile %q: %+v", profile, config)
+		}
+	}
+
+	t.Run("undeclared profiles", func(t *testing.T) {
+		var config Config
+		err := ini.NewDecoder(strings.NewReader(data)).Profile("staging").Decode(&config)
+		const expect = "unknown parent section 'prod' of section 'database' at 2:1"
+		if err == nil || err.Error() != expect {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("profile named as a section", func(t *testing.T) {
+		var config struct {
+			Database Database `ini:"database"`
+			Prod     Database `ini:"prod"`
+		}
+		const data = "[prod]\nport=1\n[database]\nport=2\n[database:prod]\nport=3\n"
+		err := ini.NewDecoder(strings.NewReader(data)).
+			Profiles("staging", "prod").
+			Profile("staging").
+			Decode(&config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if config.Database.Port != 2 || config.Prod.Port != 1 {
+			t.Errorf("unexpected config: %+v", config)
+		}
+	})
+
+	t.Run("encode", func(t *testing.T) {
+		base := tests[""]
+		prod := base
+		prod.Database.Host = "db.example.com"
+		prod.Database.User = ""
+		prod.Cache.User = "cache"
+
+		b, err := ini.MarshalProfile("prod", base, prod)
+		if err != nil {
+			t.Fatal(err)
+		}
+		const expect = "[database:prod]\nhost='db.example.com'\n[cache:prod]\nuser='cache'\n"
+		if string(b) != expect {
+			t.Errorf("unexpected result\nexpect: %q\ngot:    %q", expect, b)
+		}
+
+		var config Config
+		data, _ := ini.Marshal(base)
+		err = ini.NewDecoder(strings.NewReader(string(data) + "\n" + string(b))).
+			Profile("prod").
+			Decode(&config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if config != prod {
+			t.Errorf("unexpected config: %+v", config)
+		}
+
+		b, err = ini.MarshalProfile("prod", base, base)
+		if err != nil || len(b) != 0 {
+			t.Errorf("unexpected diff of equal values: %q, %v", b, err)
+		}
+	})
+}
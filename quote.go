@@ -0,0 +1,151 @@
+package ini
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// QuoteStyle controls when and how the encoder quotes values.
+type QuoteStyle uint8
+
+const (
+	// QuoteSingle puts strings in single quotes, other values are
+	// written as is. This is the default style.
+	QuoteSingle QuoteStyle = iota
+
+	// QuoteDouble puts strings in double quotes, other values are
+	// written as is. Double-quoted strings are decoded verbatim, so
+	// strings that need escaping are put in single quotes instead.
+	QuoteDouble
+
+	// QuoteMinimal writes strings without quotes when it is safe
+	// and puts them in single quotes otherwise.
+	QuoteMinimal
+
+	// QuoteAlways puts every value in single quotes, including numbers
+	// and booleans.
+	QuoteAlways
+)
+
+// quote encodes the string according to the quote style.
+func (opts *EncoderOptions) quote(s string) string {
+	switch opts.Quote {
+	case QuoteDouble:
+		if opts.isVerbatimSafe(s) {
+			return `"` + s + `"`
+		}
+
+	case QuoteMinimal:
+		if opts.isBareSafe(s) {
+			return s
+		}
+	}
+	return opts.quoteWith(s, '\'')
+}
+
+// quoteScalar quotes an encoded non-string value if the quote style
+// requires it.
+func (opts *EncoderOptions) quoteScalar(b []byte) []byte {
+	if opts.Quote == QuoteAlways {
+		return []byte(opts.quoteWith(string(b), '\''))
+	}
+	return b
+}
+
+func (opts *EncoderOptions) quoteWith(s string, quote byte) string {
+	buf := make([]byte, 0, 2+len(s)+len(s)/2)
+	buf = append(buf, quote)
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == rune(quote), r == '\\':
+			buf = append(buf, '\\', byte(r))
+
+		case r == '\n':
+			buf = append(buf, "\\n"...)
+
+		case r == '\r':
+			buf = append(buf, "\\r"...)
+
+		case r == '\t':
+			buf = append(buf, "\\t"...)
+
+		case r >= 0x20 && r <= 0x7E:
+			buf = append(buf, byte(r))
+
+		case r == utf8.RuneError && size == 1:
+			buf = fmt.Appendf(buf, "\\x%02x", s[i])
+
+		case r > 0x7E && opts.RawUTF8 && unicode.IsPrint(r):
+			buf = append(buf, s[i:i+size]...)
+
+		case opts.UnicodeEscapes && r <= 0xFFFF:
+			buf = fmt.Appendf(buf, "\\u%04x", r)
+
+		case opts.UnicodeEscapes:
+			buf = fmt.Appendf(buf, "\\U%08x", r)
+
+		default:
+			for _, b := range []byte(s[i : i+size]) {
+				buf = fmt.Appendf(buf, "\\x%02x", b)
+			}
+		}
+		i += size
+	}
+	buf = append(buf, quote)
+	return string(buf)
+}
+
+// isBareSafe reports whether the string can be written without quotes
+// and decoded back to the same string.
+func (opts *EncoderOptions) isBareSafe(s string) bool {
+	if s == "" || s != strings.TrimSpace(s) {
+		return false
+	}
+	if _, ok := inferType(s).(string); !ok {
+		return false
+	}
+	switch s[0] {
+	case '\'', '"', '#', ';':
+		return false
+	}
+	for _, r := range s {
+		switch {
+		case r == ',', r == ';', r == '\\':
+			return false
+
+		case r >= 0x20 && r <= 0x7E:
+			// Printable ASCII character.
+
+		case r > 0x7E && r != utf8.RuneError && opts.RawUTF8 && unicode.IsPrint(r):
+			// Printable UTF-8 character.
+
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// isVerbatimSafe reports whether the string can be written in double
+// quotes as is.
+func (opts *EncoderOptions) isVerbatimSafe(s string) bool {
+	for _, r := range s {
+		switch {
+		case r == '"':
+			return false
+
+		case r >= 0x20 && r <= 0x7E:
+			// Printable ASCII character.
+
+		case r > 0x7E && r != utf8.RuneError && opts.RawUTF8 && unicode.IsPrint(r):
+			// Printable UTF-8 character.
+
+		default:
+			return false
+		}
+	}
+	return true
+}
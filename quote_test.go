@@ -0,0 +1,104 @@
+package ini_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/saffage/go-ini"
+)
+
+func TestQuoteStyle(t *testing.T) {
+	type Section struct {
+		Name  string   `ini:"name"`
+		Text  string   `ini:"text"`
+		Count int      `ini:"count"`
+		Tags  []string `ini:"tags"`
+	}
+	type file struct {
+		S Section
+	}
+	value := file{
+		S: Section{
+			Name:  "party 🎉",
+			Text:  "it's a \"test\"\n",
+			Count: 3,
+			Tags:  []string{"a", "1"},
+		},
+	}
+	tests := []struct {
+		name   string
+		opts   ini.EncoderOptions
+		expect string
+	}{
+		{
+			name: "single",
+			opts: ini.EncoderOptions{},
+			expect: "[S]\nname='party \\xf0\\x9f\\x8e\\x89'\n" +
+				"text='it\\'s a \"test\"\\n'\ncount=3\ntags='a','1'\n",
+		},
+		{
+			name: "double",
+			opts: ini.EncoderOptions{Quote: ini.QuoteDouble, RawUTF8: true},
+			expect: "[S]\nname=\"party 🎉\"\n" +
+				"text='it\\'s a \"test\"\\n'\ncount=3\ntags=\"a\",\"1\"\n",
+		},
+		{
+			name: "minimal",
+			opts: ini.EncoderOptions{Quote: ini.QuoteMinimal, RawUTF8: true},
+			expect: "[S]\nname=party 🎉\n" +
+				"text='it\\'s a \"test\"\\n'\ncount=3\ntags=a,'1'\n",
+		},
+		{
+			name: "always",
+			opts: ini.EncoderOptions{Quote: ini.QuoteAlways, UnicodeEscapes: true},
+			expect: "[S]\nname='party \\U0001f389'\n" +
+				"text='it\\'s a \"test\"\\n'\ncount='3'\ntags='a','1'\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf := bytes.Buffer{}
+			if err := ini.NewEncoder(&buf).Options(test.opts).Encode(value); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != test.expect {
+				t.Errorf("unexpected encoder output\nexpect:\n%s\ngot:\n%s", test.expect, buf.String())
+			}
+
+			var decoded file
+			if err := ini.Unmarshal(buf.Bytes(), &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if decoded.S.Name != value.S.Name || decoded.S.Text != value.S.Text ||
+				decoded.S.Count != value.S.Count || len(decoded.S.Tags) != 2 {
+				t.Errorf("unexpected decoded value: %+v", decoded)
+			}
+		})
+	}
+}
+
+func TestDoubleQuotedVerbatim(t *testing.T) {
+	type file struct {
+		S struct {
+			Path string `ini:"path"`
+		}
+	}
+
+	var decoded file
+	if err := ini.Unmarshal([]byte(`[S]`+"\n"+`path="C:\dir\file"`), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.S.Path != `C:\dir\file` {
+		t.Errorf("unexpected path: %q", decoded.S.Path)
+	}
+
+	buf := bytes.Buffer{}
+	opts := ini.EncoderOptions{Quote: ini.QuoteDouble}
+	if err := ini.NewEncoder(&buf).Options(opts).Encode(decoded); err != nil {
+		t.Fatal(err)
+	}
+	const expect = "[S]\npath=\"C:\\dir\\file\"\n"
+	if buf.String() != expect {
+		t.Errorf("unexpected encoder output\nexpect:\n%s\ngot:\n%s", expect, buf.String())
+	}
+}
@@ -0,0 +1,211 @@
+package ini
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var (
+	tRawValue   = reflect.TypeFor[RawValue]()
+	tRawSection = reflect.TypeFor[RawSection]()
+)
+
+// RawValue is a value of a key as it is written in the file, without
+// a trailing comment. It can be used to delay decoding of a value until
+// its type is known, see [RawValue.Decode]. A RawValue is encoded
+// unchanged.
+//
+// References and environment variables in the value are not expanded.
+type RawValue struct {
+	Text   string
+	File   string // Name of the file, if known.
+	Line   int    // Zero if the value is not written in a file.
+	Column int    // Zero if the value is not written in a file.
+}
+
+// Decode decodes the value into target, which must be a non-nil pointer
+// to a type a field can have (see [SectionsOf]).
+func (raw RawValue) Decode(target any) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return errors.New("target must be a non-nil pointer")
+	}
+
+	key, err := raw.key("")
+	if err != nil {
+		return err
+	}
+
+	if err := decodeValue(key, v.Elem()); err != nil {
+		return raw.error(err)
+	}
+	return nil
+}
+
+// key parses the value as the value of the key named name.
+func (raw RawValue) key(name string) (docKey, error) {
+	d := Decoder{}
+	d.init([]byte(raw.Text))
+
+	value, err := d.value()
+	if err == nil && d.peek() != '\000' {
+		err = errUnexpectedChar(d.peek(), d.lineNum, d.charNum)
+	}
+	if err != nil {
+		return docKey{}, raw.error(err)
+	}
+
+	return docKey{
+		name:   name,
+		value:  value,
+		file:   raw.File,
+		line:   uint32(raw.Line),
+		column: uint32(raw.Column),
+	}, nil
+}
+
+func (raw RawValue) error(err error) error {
+	if raw.Line == 0 {
+		return err
+	}
+	key := docKey{file: raw.File, line: uint32(raw.Line), column: uint32(raw.Column)}
+	return fmt.Errorf("value at %s: %w", key.position(), err)
+}
+
+// RawKey is a key of a [RawSection].
+type RawKey struct {
+	Name  string
+	Value RawValue
+}
+
+// RawSection is a section whose keys are kept as they are written in
+// the file. It can be used to delay decoding of a section until its type
+// is known, see [RawSection.Decode]. Keys of a RawSection are encoded
+// unchanged.
+type RawSection struct {
+	Name   string
+	Keys   []RawKey
+	File   string // Name of the file, if known.
+	Line   int    // Zero if the section is not written in a file.
+	Column int    // Zero if the section is not written in a file.
+}
+
+// Decode decodes the keys of the section into target, which must be
+// a non-nil pointer to a type a section can have (see [SectionsOf]).
+// Default values are applied and constraints of the fields are checked
+// as by [Decoder.Decode].
+func (raw *RawSection) Decode(target any) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return errors.New("target must be a non-nil pointer")
+	}
+
+	section, err := (&treeOptions{}).sectionOf(
+		v.Elem(),
+		nil,
+		reflect.StructField{},
+		flags{key: raw.Name},
+	)
+	if err != nil {
+		return err
+	}
+
+	s := &docSection{
+		name:   raw.Name,
+		file:   raw.File,
+		line:   uint32(raw.Line),
+		column: uint32(raw.Column),
+	}
+	for _, rawKey := range raw.Keys {
+		key, err := rawKey.Value.key(rawKey.Name)
+		if err != nil {
+			return err
+		}
+		s.set(key)
+	}
+
+	doc := &document{sections: []*docSection{s}}
+	sections := []Section{section}
+
+	d := Decoder{}
+	if err := d.apply(doc, sections, reflect.Value{}, nil); err != nil {
+		return err
+	}
+	return validate(doc, sections, reflect.Value{})
+}
+
+// rawSectionOf builds a section from a [RawSection] value.
+func rawSectionOf(v reflect.Value, flags flags) Section {
+	raw := v.Interface().(RawSection)
+
+	// Keys are addressable even if the section is not.
+	fields := make([]Field, len(raw.Keys))
+	for i := range raw.Keys {
+		fields[i] = Field{
+			Name:  raw.Keys[i].Name,
+			Value: reflect.ValueOf(&raw.Keys[i].Value).Elem(),
+		}
+	}
+
+	return Section{
+		Name:      flags.key,
+		Fields:    fields,
+		OmitEmpty: flags.omitempty,
+		Doc:       flags.doc,
+		null:      flags.alloc != nil,
+		alloc:     flags.alloc,
+		value:     v,
+	}
+}
+
+// decodeRawSection stores the keys of the document section in
+// the [RawSection] the section was built from.
+func decodeRawSection(section *Section, s *docSection) {
+	raw := section.value.Addr().Interface().(*RawSection)
+	raw.Name = s.name
+	raw.File, raw.Line, raw.Column = s.file, int(s.line), int(s.column)
+
+	for _, key := range s.keys {
+		value := rawValueOf(key)
+		i := 0
+		for i < len(raw.Keys) && raw.Keys[i].Name != key.name {
+			i++
+		}
+		if i < len(raw.Keys) {
+			raw.Keys[i].Value = value
+		} else {
+			raw.Keys = append(raw.Keys, RawKey{Name: key.name, Value: value})
+		}
+	}
+
+	if section.alloc != nil {
+		section.alloc()
+	}
+}
+
+// rawValueOf returns the value of the key as it is written in the file.
+// Values that are not written in a file, such as values of environment
+// variables, are quoted if needed.
+func rawValueOf(key docKey) RawValue {
+	text := key.value.raw
+	if text == "" && key.value.text != "" {
+		opts := EncoderOptions{Quote: QuoteMinimal, RawUTF8: true}
+		text = opts.quote(key.value.text)
+	}
+	return RawValue{
+		Text:   text,
+		File:   key.file,
+		Line:   int(key.line),
+		Column: int(key.column),
+	}
+}
+
+// decodeValue decodes the value of the key into v.
+func decodeValue(key docKey, v reflect.Value) error {
+	if v.Type() == tRawValue && v.CanSet() {
+		v.Set(reflect.ValueOf(rawValueOf(key)))
+		return nil
+	}
+	return decode(key.value.text, v)
+}
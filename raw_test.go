@@ -0,0 +1,98 @@
+package ini_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/saffage/go-ini"
+)
+
+func TestRaw(t *testing.T) {
+	type Postgres struct {
+		Host  string   `ini:"host"`
+		Port  int      `ini:"port,default=5432"`
+		Hosts []string `ini:"replicas"`
+	}
+	type Config struct {
+		Storage struct {
+			Type    string       `ini:"type"`
+			Timeout ini.RawValue `ini:"timeout"`
+		} `ini:"storage"`
+		Backend ini.RawSection  `ini:"backend"`
+		Spare   *ini.RawSection `ini:"spare"`
+	}
+
+	const data = "[storage]\ntype=postgres\ntimeout = 30 ; seconds\n" +
+		"[backend]\nhost = 'db.example.com'\nreplicas=a, \"b\"\n"
+
+	var config Config
+	if err := ini.Unmarshal([]byte(data), &config); err != nil {
+		t.Fatal(err)
+	}
+
+	timeout := ini.RawValue{Text: "30", Line: 3, Column: 1}
+	if config.Storage.Timeout != timeout {
+		t.Errorf("unexpected raw value: %+v", config.Storage.Timeout)
+	}
+
+	backend := ini.RawSection{
+		Name: "backend",
+		Keys: []ini.RawKey{
+			{"host", ini.RawValue{Text: "'db.example.com'", Line: 5, Column: 1}},
+			{"replicas", ini.RawValue{Text: `a, "b"`, Line: 6, Column: 1}},
+		},
+		Line:   4,
+		Column: 1,
+	}
+	if !reflect.DeepEqual(config.Backend, backend) {
+		t.Errorf("unexpected raw section: %+v", config.Backend)
+	}
+	if config.Spare != nil {
+		t.Errorf("missing raw section was allocated: %+v", config.Spare)
+	}
+
+	var seconds int
+	if err := config.Storage.Timeout.Decode(&seconds); err != nil || seconds != 30 {
+		t.Errorf("unexpected decoded value: %d, %v", seconds, err)
+	}
+
+	var postgres Postgres
+	if err := config.Backend.Decode(&postgres); err != nil {
+		t.Fatal(err)
+	}
+	expect := Postgres{Host: "db.example.com", Port: 5432, Hosts: []string{"a", "b"}}
+	if !reflect.DeepEqual(postgres, expect) {
+		t.Errorf("unexpected decoded section: %+v", postgres)
+	}
+
+	testMarshal(
+		t,
+		"[storage]\ntype='postgres'\ntimeout=30\n"+
+			"[backend]\nhost='db.example.com'\nreplicas=a, \"b\"\n",
+		config,
+	)
+
+	t.Run("errors", func(t *testing.T) {
+		var n int
+		raw := ini.RawValue{Text: "'abc", Line: 2, Column: 3}
+		err := raw.Decode(&n)
+		const expect = "value at 2:3: unterminated string at 1:5"
+		if err == nil || err.Error() != expect {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		raw = ini.RawValue{Text: "abc"}
+		if err := raw.Decode(&n); err == nil || !strings.Contains(err.Error(), "invalid syntax") {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if err := raw.Decode(n); err == nil {
+			t.Error("expected an error for a non-pointer target")
+		}
+
+		section := ini.RawSection{Name: "s", Keys: []ini.RawKey{{"port", ini.RawValue{Text: "x"}}}}
+		if err := section.Decode(&postgres); err == nil {
+			t.Error("expected an error for an invalid value")
+		}
+	})
+}
@@ -0,0 +1,148 @@
+package ini
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Template renders an example INI file for the value provided.
+//
+// Every key is commented out and written with its current value or, if
+// the value is zero, with the default one (see the 'default' flag in the
+// [SectionsOf] documentation). Documentation of the key is followed by a hint
+// of its type and, if known, the list of values it can have:
+//
+//	; Width of the window.
+//	; Type: int
+//	;width=1024
+//
+// Sections built from nil pointers are written as if they had zero values.
+func Template(value any) ([]byte, error) {
+	buf := bytes.Buffer{}
+	e := Encoder{}
+	e.Reset(&buf)
+	if err := e.EncodeTemplate(value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// EncodeTemplate writes an example INI file for the value provided.
+//
+// More information can be found in the [Template] function documentation.
+func (e *Encoder) EncodeTemplate(value any) error {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() {
+		return fmt.Errorf("invalid value for template")
+	}
+
+	// Work on an addressable copy to allocate nil pointers.
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v = reflect.New(v.Type().Elem())
+		}
+	} else {
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		v = ptr
+	}
+
+	sections, err := e.tree.sectionsOf(v.Interface())
+	if err != nil {
+		return err
+	}
+
+	template := make([]Section, 0, len(sections))
+	for _, section := range sections {
+		if section.null && len(section.Fields) == 0 {
+			continue
+		}
+
+		section.null = false
+		section.OmitEmpty = false
+		section.Fields = append([]Field(nil), section.Fields...)
+
+		for i := range section.Fields {
+			field := &section.Fields[i]
+			field.Commented = true
+			field.OmitEmpty = false
+			field.Doc = templateDoc(*field)
+			if def, ok := field.defaultValue(); ok && field.Value.IsZero() {
+				field.Value = def
+			}
+		}
+
+		template = append(template, section)
+	}
+
+	return e.Encode(template)
+}
+
+func templateDoc(field Field) string {
+	doc := strings.Builder{}
+	if field.Doc != "" {
+		doc.WriteString(field.Doc)
+	}
+
+	v := field.Value
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if v.IsValid() {
+		if doc.Len() > 0 {
+			doc.WriteByte('\n')
+		}
+		doc.WriteString("Type: ")
+		doc.WriteString(typeHint(v.Type()))
+	}
+
+	if field.Required {
+		doc.WriteString(" (required)")
+	}
+
+	allowed := field.Allowed
+	if allowed == nil && v.Kind() == reflect.Bool {
+		allowed = []string{"true", "false"}
+	}
+	if len(allowed) > 0 {
+		if doc.Len() > 0 {
+			doc.WriteByte('\n')
+		}
+		doc.WriteString("Allowed values: ")
+		doc.WriteString(strings.Join(allowed, ", "))
+	}
+
+	return doc.String()
+}
+
+// typeHint returns a human-readable name of the type.
+func typeHint(t reflect.Type) string {
+	if t.Implements(tTextMarshaler) || reflect.PointerTo(t).Implements(tTextMarshaler) {
+		return "text"
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "bool"
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "int"
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "uint"
+
+	case reflect.Float32, reflect.Float64:
+		return "float"
+
+	case reflect.String:
+		return "string"
+
+	case reflect.Array, reflect.Slice:
+		return "list of " + typeHint(t.Elem())
+
+	default:
+		return "any"
+	}
+}
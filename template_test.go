@@ -0,0 +1,69 @@
+package ini_test
+
+import (
+	"testing"
+
+	"github.com/saffage/go-ini"
+)
+
+func TestTemplate(t *testing.T) {
+	type Server struct {
+		Host  string   `ini:"host" comment:"Address to listen on."`
+		Port  int      `ini:"port"`
+		Level string   `ini:"level,oneof=debug info" comment:"Log level."`
+		Debug bool     `ini:"debug,omitempty"`
+		Peers []string `ini:"peers"`
+	}
+	type Config struct {
+		Server Server `comment:"Server settings."`
+		Backup *Server
+	}
+
+	b, err := ini.Template(Config{
+		Server: Server{Host: "localhost", Port: 8080, Level: "info"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const expect = `; Server settings.
+[Server]
+; Address to listen on.
+; Type: string
+;host='localhost'
+; Type: int
+;port=8080
+; Log level.
+; Type: string
+; Allowed values: debug, info
+;level='info'
+; Type: bool
+; Allowed values: true, false
+;debug=false
+; Type: list of string
+;peers=
+[Backup]
+; Address to listen on.
+; Type: string
+;host=''
+; Type: int
+;port=0
+; Log level.
+; Type: string
+; Allowed values: debug, info
+;level=''
+; Type: bool
+; Allowed values: true, false
+;debug=false
+; Type: list of string
+;peers=
+`
+	if string(b) != expect {
+		t.Errorf("unexpected template\nexpect:\n%s\ngot:\n%s", expect, b)
+	}
+
+	var config Config
+	if err := ini.Unmarshal(b, &config); err != nil {
+		t.Fatal(err)
+	}
+}
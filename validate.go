@@ -0,0 +1,255 @@
+package ini
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Validator interface can be implemented by sections and the root value
+// to check them after decoding.
+type Validator interface {
+	Validate() error
+}
+
+// ValidationError is a report of every constraint violation found
+// after decoding.
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		lines[i] = err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+// FieldError describes a violation of a constraint of a key or a section.
+type FieldError struct {
+	Section string // Empty if the error is reported by the root value.
+	Key     string // Empty if the error is reported by the section.
+	File    string // Name of the file, if known.
+	Line    int    // Zero if the key is missing in the file.
+	Err     error
+}
+
+func (e *FieldError) Error() string {
+	buf := strings.Builder{}
+	if e.Section != "" {
+		buf.WriteString("[" + e.Section + "]")
+	}
+	if e.Key != "" {
+		buf.WriteString(" " + e.Key)
+	}
+	if e.Line > 0 && e.File != "" {
+		fmt.Fprintf(&buf, " (%s, line %d)", e.File, e.Line)
+	} else if e.Line > 0 {
+		fmt.Fprintf(&buf, " (line %d)", e.Line)
+	}
+	if buf.Len() > 0 {
+		buf.WriteString(": ")
+	}
+	buf.WriteString(e.Err.Error())
+	return buf.String()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// rules are constraints of a field checked after decoding.
+type rules struct {
+	min, max *float64
+	length   *int
+	regex    *regexp.Regexp
+}
+
+// validate checks constraints of every field and calls [Validator]
+// implementations of sections and the root value.
+func validate(doc *document, sections []Section, root reflect.Value) error {
+	report := &ValidationError{}
+
+	for _, section := range sections {
+		s := doc.section(section.Name)
+		if s == nil && section.null {
+			continue
+		}
+
+		for _, field := range section.Fields {
+			key, present := docKey{}, false
+			if s != nil {
+				key, present = s.key(field.Name)
+			}
+
+			err := error(nil)
+			switch {
+			case field.Required && !present:
+				err = errors.New("required key is missing")
+
+			case present || field.Default != "":
+				err = field.rules.check(field.Value, field.Allowed)
+			}
+
+			if err != nil {
+				report.Errors = append(report.Errors, &FieldError{
+					Section: section.Name,
+					Key:     field.Name,
+					File:    key.file,
+					Line:    int(key.line),
+					Err:     err,
+				})
+			}
+		}
+
+		if err := callValidator(section.value); err != nil {
+			fieldErr := &FieldError{Section: section.Name, Err: err}
+			if s != nil {
+				fieldErr.File, fieldErr.Line = s.file, int(s.line)
+			}
+			report.Errors = append(report.Errors, fieldErr)
+		}
+	}
+
+	if err := callValidator(root); err != nil {
+		report.Errors = append(report.Errors, &FieldError{Err: err})
+	}
+
+	if len(report.Errors) > 0 {
+		return report
+	}
+	return nil
+}
+
+func callValidator(v reflect.Value) error {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Kind() != reflect.Pointer && v.CanAddr() {
+		v = v.Addr()
+	}
+	if v.Kind() == reflect.Pointer && v.IsNil() || !v.CanInterface() {
+		return nil
+	}
+	if validator, ok := v.Interface().(Validator); ok {
+		return validator.Validate()
+	}
+	return nil
+}
+
+func (r *rules) check(v reflect.Value, allowed []string) error {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return r.checkNumber(float64(v.Int()), itemText(v), allowed)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return r.checkNumber(float64(v.Uint()), itemText(v), allowed)
+
+	case reflect.Float32, reflect.Float64:
+		return r.checkNumber(v.Float(), itemText(v), allowed)
+
+	case reflect.String:
+		if err := r.checkLength(utf8.RuneCountInString(v.String())); err != nil {
+			return err
+		}
+		return r.checkItem(v, allowed)
+
+	case reflect.Array, reflect.Slice:
+		if err := r.checkLength(v.Len()); err != nil {
+			return err
+		}
+		for i := range v.Len() {
+			if err := r.checkItem(v.Index(i), allowed); err != nil {
+				return fmt.Errorf("item %d: %w", i, err)
+			}
+		}
+		return nil
+
+	default:
+		return r.checkItem(v, allowed)
+	}
+}
+
+func (r *rules) checkNumber(x float64, text string, allowed []string) error {
+	if r.min != nil && x < *r.min {
+		return fmt.Errorf("must be at least %v", *r.min)
+	}
+	if r.max != nil && x > *r.max {
+		return fmt.Errorf("must be at most %v", *r.max)
+	}
+	if len(allowed) > 0 && !slices.Contains(allowed, text) {
+		return errNotOneOf(allowed)
+	}
+	return nil
+}
+
+func (r *rules) checkLength(n int) error {
+	if r.length != nil && n != *r.length {
+		return fmt.Errorf("length must be %d", *r.length)
+	}
+	if r.min != nil && float64(n) < *r.min {
+		return fmt.Errorf("length must be at least %v", *r.min)
+	}
+	if r.max != nil && float64(n) > *r.max {
+		return fmt.Errorf("length must be at most %v", *r.max)
+	}
+	return nil
+}
+
+func (r *rules) checkItem(v reflect.Value, allowed []string) error {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if !v.IsValid() || !v.CanInterface() {
+		return nil
+	}
+	text := itemText(v)
+	if r.regex != nil && v.Kind() == reflect.String && !r.regex.MatchString(text) {
+		return fmt.Errorf("must match '%s'", r.regex.String())
+	}
+	if len(allowed) > 0 && !slices.Contains(allowed, text) {
+		return errNotOneOf(allowed)
+	}
+	return nil
+}
+
+// itemText formats the value to compare it with the values of the 'oneof'
+// flag. Numbers are formatted without exponents.
+func itemText(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits())
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func errNotOneOf(allowed []string) error {
+	return fmt.Errorf("must be one of: %s", strings.Join(allowed, ", "))
+}
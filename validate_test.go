@@ -0,0 +1,78 @@
+package ini_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/saffage/go-ini"
+)
+
+type validatedServer struct {
+	Host  string   `ini:"host,required,regex=^[a-z.]+$"`
+	Port  int      `ini:"port,min=1,max=65535"`
+	Level string   `ini:"level,oneof=debug info,default=info"`
+	Tags  []string `ini:"tags,len=2"`
+	Name  string   `ini:"name,min=2"`
+}
+
+func (s *validatedServer) Validate() error {
+	if s.Host == "localhost" && s.Port == 80 {
+		return errors.New("port 80 is reserved")
+	}
+	return nil
+}
+
+type validatedConfig struct {
+	Server validatedServer
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		var config validatedConfig
+		data := "[Server]\nhost=example.com\nport=8080\ntags=a,b\n"
+		if err := ini.Unmarshal([]byte(data), &config); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("invalid", func(t *testing.T) {
+		var config validatedConfig
+		data := "[Server]\nport=0\nlevel=trace\ntags=a\nname=x\n"
+		err := ini.Unmarshal([]byte(data), &config)
+
+		report := (*ini.ValidationError)(nil)
+		if !errors.As(err, &report) {
+			t.Fatalf("expected a validation error, got %v", err)
+		}
+
+		const expect = "[Server] host: required key is missing\n" +
+			"[Server] port (line 2): must be at least 1\n" +
+			"[Server] level (line 3): must be one of: debug, info\n" +
+			"[Server] tags (line 4): length must be 2\n" +
+			"[Server] name (line 5): length must be at least 2"
+		if err.Error() != expect {
+			t.Errorf("unexpected report\nexpect:\n%s\ngot:\n%s", expect, err)
+		}
+	})
+	t.Run("oneof large numbers", func(t *testing.T) {
+		var config struct {
+			S struct {
+				N int      `ini:"n,oneof=1000000 2000000"`
+				F float64  `ini:"f,oneof=1000000 0.5"`
+				U []uint64 `ini:"u,oneof=10000000 20000000"`
+			}
+		}
+		data := "[S]\nn=1000000\nf=1000000\nu=20000000,10000000\n"
+		if err := ini.Unmarshal([]byte(data), &config); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("validator", func(t *testing.T) {
+		var config validatedConfig
+		data := "[Server]\nhost=localhost\nport=80\n"
+		err := ini.Unmarshal([]byte(data), &config)
+		const expect = "[Server] (line 1): port 80 is reserved"
+		if err == nil || err.Error() != expect {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
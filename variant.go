@@ -0,0 +1,284 @@
+package ini
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// VariantKey is the name of the key that selects the type of a variant
+// section, see [RegisterVariant].
+const VariantKey = "type"
+
+// variant is a concrete type of an interface registered by
+// [RegisterVariant].
+type variant struct {
+	name string
+	t    reflect.Type
+}
+
+var variants = struct {
+	sync.RWMutex
+	m map[reflect.Type][]variant
+}{m: map[reflect.Type][]variant{}}
+
+// RegisterVariant registers the type of value as a variant of the interface
+// I named name.
+//
+// A section field of type I is decoded into a new value of the variant
+// selected by the key named [VariantKey], and a variant is encoded with
+// this key written first:
+//
+//	ini.RegisterVariant[Sink]("kafka", KafkaSink{})
+//	ini.RegisterVariant[Sink]("file", &FileSink{})
+//
+//	type Config struct {
+//		Main Sink `ini:"sink.main"`
+//	}
+//
+//	[sink.main]
+//	type=kafka
+//	brokers=a:9092, b:9092
+//
+// Root values of type map[string]I are supported as well. Decoded
+// values have the type of the registered value. References in the value
+// of the key are expanded, see [Decoder.Interpolate] and
+// [Decoder.ExpandEnv].
+//
+// RegisterVariant panics if I is not an interface type, the value is nil,
+// or the name is already registered for I.
+func RegisterVariant[I any](name string, value I) {
+	iface := reflect.TypeFor[I]()
+	if iface.Kind() != reflect.Interface {
+		panic(fmt.Sprintf("ini: type %s is not an interface", iface.String()))
+	}
+
+	t := reflect.TypeOf(value)
+	if t == nil {
+		panic("ini: cannot register a nil variant")
+	}
+
+	if name == "" {
+		panic("ini: empty variant name")
+	}
+
+	variants.Lock()
+	defer variants.Unlock()
+
+	for _, v := range variants.m[iface] {
+		if v.name == name {
+			panic(fmt.Sprintf(
+				"ini: variant '%s' of type %s is already registered",
+				name,
+				iface.String(),
+			))
+		}
+	}
+
+	variants.m[iface] = append(variants.m[iface], variant{name: name, t: t})
+}
+
+// variantsOf returns the registered variants of the type, which are empty
+// if it is not an interface.
+func variantsOf(t reflect.Type) []variant {
+	if t.Kind() != reflect.Interface {
+		return nil
+	}
+	variants.RLock()
+	defer variants.RUnlock()
+	return variants.m[t]
+}
+
+// variantName returns the name of the variant of the value.
+func variantName(iface reflect.Type, v reflect.Value) (string, error) {
+	t := v.Type()
+	for _, variant := range variantsOf(iface) {
+		if variant.t == t || reflect.PointerTo(variant.t) == t ||
+			variant.t.Kind() == reflect.Pointer && variant.t.Elem() == t {
+			return variant.name, nil
+		}
+	}
+	return "", fmt.Errorf(
+		"type %s is not a registered variant of type %s",
+		t.String(),
+		iface.String(),
+	)
+}
+
+// variantSection adds the key naming the variant to the section built from
+// the value of the interface type.
+func variantSection(section Section, iface reflect.Type, v reflect.Value) (Section, error) {
+	if !v.IsValid() || len(variantsOf(iface)) == 0 {
+		return section, nil
+	}
+
+	name, err := variantName(iface, v)
+	if err != nil {
+		return section, err
+	}
+
+	discriminator := Field{
+		Name:  VariantKey,
+		Value: reflect.ValueOf(&name).Elem(),
+	}
+	section.Fields = slices.Insert(slices.Clip(section.Fields), 0, discriminator)
+	return section, nil
+}
+
+// resolveVariants sets variant sections of the root value to new values of
+// the variants named in the document. Names of the variants are the values
+// of the keys returned by expand. The returned function converts pointers
+// to values registered as non-pointers back after decoding, it must be
+// called even if an error is returned and does nothing when called again.
+func resolveVariants(
+	opts *treeOptions,
+	doc *document,
+	value any,
+	expand func(s *docSection, key docKey) (string, error),
+) (func(), error) {
+	var fixups []func()
+	restore := func() {
+		for _, fixup := range fixups {
+			fixup()
+		}
+		fixups = nil
+	}
+
+	root := reflect.Indirect(reflect.ValueOf(value))
+
+	switch {
+	case root.Kind() == reflect.Struct:
+		for i := range root.NumField() {
+			field := root.Type().Field(i)
+			if !field.IsExported() || len(variantsOf(field.Type)) == 0 {
+				continue
+			}
+
+			flags, err := parseTag(root.Type(), field)
+			if err != nil {
+				continue
+			}
+			if flags.key == "-" || opts.fallbackTag(field, &flags) {
+				continue
+			}
+			if flags.key == "" {
+				flags.key = opts.fieldName(field.Name)
+			}
+
+			s := doc.section(flags.key)
+			if s == nil {
+				continue
+			}
+
+			fieldValue := root.Field(i)
+			ptr, fixup, err := newVariant(field.Type, s, fieldValue, expand)
+			if err != nil {
+				return restore, err
+			}
+			if ptr.IsValid() {
+				fieldValue.Set(ptr)
+			}
+			if fixup != nil {
+				fixups = append(fixups, func() { fieldValue.Set(fixup()) })
+			}
+		}
+
+	case root.Kind() == reflect.Map && len(variantsOf(root.Type().Elem())) > 0:
+		if root.IsNil() && len(doc.sections) > 0 {
+			root.Set(reflect.MakeMap(root.Type()))
+		}
+
+		for _, s := range doc.sections {
+			name := reflect.ValueOf(s.name).Convert(root.Type().Key())
+			existing := root.MapIndex(name)
+			if !existing.IsValid() {
+				existing = reflect.New(root.Type().Elem()).Elem()
+			}
+
+			ptr, fixup, err := newVariant(root.Type().Elem(), s, existing, expand)
+			if err != nil {
+				return restore, err
+			}
+			if ptr.IsValid() {
+				root.SetMapIndex(name, ptr)
+			}
+			if fixup != nil {
+				fixups = append(fixups, func() { root.SetMapIndex(name, fixup()) })
+			}
+		}
+	}
+
+	return restore, nil
+}
+
+// newVariant returns a pointer to the value of the variant named in
+// the section, reusing the current value if it has the same type. If
+// the section names no variant, the current value is kept and ptr is
+// invalid. If the variant is registered as a non-pointer, fixup returns
+// the value the pointer points to.
+func newVariant(
+	iface reflect.Type,
+	s *docSection,
+	current reflect.Value,
+	expand func(s *docSection, key docKey) (string, error),
+) (ptr reflect.Value, fixup func() reflect.Value, err error) {
+	key, ok := s.key(VariantKey)
+	if !ok {
+		if !current.IsNil() {
+			return reflect.Value{}, nil, nil
+		}
+		return reflect.Value{}, nil, fmt.Errorf(
+			"missing key '%s' in section '%s' at %s",
+			VariantKey,
+			s.name,
+			s.position(),
+		)
+	}
+
+	name, err := expand(s, key)
+	if err != nil {
+		return reflect.Value{}, nil, err
+	}
+
+	names := []string{}
+	for _, variant := range variantsOf(iface) {
+		names = append(names, variant.name)
+		if variant.name != name {
+			continue
+		}
+
+		t := variant.t
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+
+		switch {
+		case current.IsNil():
+			ptr = reflect.New(t)
+
+		case current.Elem().Type() == reflect.PointerTo(t):
+			ptr = current.Elem()
+
+		default:
+			ptr = reflect.New(t)
+			if current.Elem().Type() == t {
+				ptr.Elem().Set(current.Elem())
+			}
+		}
+
+		if variant.t.Kind() != reflect.Pointer {
+			fixup = func() reflect.Value { return ptr.Elem() }
+		}
+		return ptr, fixup, nil
+	}
+
+	return reflect.Value{}, nil, fmt.Errorf(
+		"unknown type '%s' in key '%s' at %s, expected one of: %s",
+		name,
+		key.name,
+		key.position(),
+		strings.Join(names, ", "),
+	)
+}
@@ -0,0 +1,160 @@
+package ini_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/saffage/go-ini"
+)
+
+type sink interface {
+	Kind() string
+}
+
+type kafkaSink struct {
+	Brokers []string `ini:"brokers"`
+	Topic   string   `ini:"topic"`
+}
+
+func (kafkaSink) Kind() string { return "kafka" }
+
+type fileSink struct {
+	Path string `ini:"path"`
+}
+
+func (*fileSink) Kind() string { return "file" }
+
+// brokenSink is a variant of another interface with an invalid tag.
+type brokenSink struct {
+	Path string `ini:"path,required,required"`
+}
+
+func (brokenSink) Kind() string { return "broken" }
+
+type brokenSinkIface interface {
+	Kind() string
+	broken()
+}
+
+func (brokenSink) broken() {}
+
+func init() {
+	ini.RegisterVariant[sink]("kafka", kafkaSink{})
+	ini.RegisterVariant[sink]("file", &fileSink{})
+	ini.RegisterVariant[brokenSinkIface]("broken", brokenSink{})
+}
+
+func TestVariants(t *testing.T) {
+	type config struct {
+		Main   sink `ini:"sink.main"`
+		Backup sink `ini:"sink.backup"`
+		Spare  sink `ini:"sink.spare"`
+	}
+
+	const data = "[sink.main]\ntype=kafka\nbrokers=a:9092, b:9092\ntopic=logs\n" +
+		"[sink.backup]\ntype=file\npath=/var/log/app.log\n"
+
+	var c config
+	if err := ini.Unmarshal([]byte(data), &c); err != nil {
+		t.Fatal(err)
+	}
+	expect := config{
+		Main:   kafkaSink{Brokers: []string{"a:9092", "b:9092"}, Topic: "logs"},
+		Backup: &fileSink{Path: "/var/log/app.log"},
+	}
+	if !reflect.DeepEqual(c, expect) {
+		t.Errorf("unexpected value: %#v", c)
+	}
+
+	testMarshal(
+		t,
+		"[sink.main]\ntype='kafka'\nbrokers='a:9092','b:9092'\ntopic='logs'\n"+
+			"[sink.backup]\ntype='file'\npath='/var/log/app.log'\n",
+		c,
+	)
+
+	t.Run("map", func(t *testing.T) {
+		sinks := map[string]sink{}
+		if err := ini.Unmarshal([]byte(data), &sinks); err != nil {
+			t.Fatal(err)
+		}
+		expect := map[string]sink{"sink.main": expect.Main, "sink.backup": expect.Backup}
+		if !reflect.DeepEqual(sinks, expect) {
+			t.Errorf("unexpected value: %#v", sinks)
+		}
+	})
+
+	t.Run("existing value", func(t *testing.T) {
+		c := config{Main: kafkaSink{Topic: "events"}}
+		data := "[sink.main]\ntype=kafka\nbrokers=c\n"
+		if err := ini.Unmarshal([]byte(data), &c); err != nil {
+			t.Fatal(err)
+		}
+		expect := kafkaSink{Brokers: []string{"c"}, Topic: "events"}
+		if !reflect.DeepEqual(c.Main, expect) {
+			t.Errorf("unexpected value: %#v", c.Main)
+		}
+	})
+
+	t.Run("expanded type", func(t *testing.T) {
+		lookup := func(key string) (string, bool) {
+			return "kafka", key == "SINK_TYPE"
+		}
+		data := "[sink.main]\ntype=${SINK_TYPE}\ntopic=logs\n" +
+			"[sink.backup]\nkind=file\ntype=${kind}\npath=app.log\n"
+
+		var c config
+		d := ini.NewDecoder(strings.NewReader(data)).
+			Interpolate(true).
+			ExpandEnv(true).
+			LookupEnv(lookup)
+		if err := d.Decode(&c); err != nil {
+			t.Fatal(err)
+		}
+		expect := config{
+			Main:   kafkaSink{Topic: "logs"},
+			Backup: &fileSink{Path: "app.log"},
+		}
+		if !reflect.DeepEqual(c, expect) {
+			t.Errorf("unexpected value: %#v", c)
+		}
+	})
+
+	t.Run("restore on error", func(t *testing.T) {
+		var c struct {
+			Main brokenSinkIface `ini:"sink.main"`
+		}
+		err := ini.Unmarshal([]byte("[sink.main]\ntype=broken\n"), &c)
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+		if _, ok := c.Main.(brokenSink); !ok {
+			t.Errorf("unexpected value: %#v", c.Main)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := map[string]string{
+			"[sink.main]\ntopic=logs\n": "missing key 'type' in section 'sink.main' at 1:1",
+			"[sink.main]\ntype=http\n": "unknown type 'http' in key 'type' at 2:1, " +
+				"expected one of: kafka, file",
+		}
+		for data, expect := range tests {
+			var c config
+			err := ini.Unmarshal([]byte(data), &c)
+			if err == nil || err.Error() != expect {
+				t.Errorf("unexpected error for %q\nexpect: %s\ngot:    %v", data, expect, err)
+			}
+		}
+
+		_, err := ini.Marshal(config{Main: unknownSink{}})
+		if err == nil || !strings.Contains(err.Error(), "is not a registered variant") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+type unknownSink struct{}
+
+func (unknownSink) Kind() string { return "unknown" }